@@ -0,0 +1,160 @@
+package omreport
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs are the cache TTLs used for command families when CacheConfig.TTLs is nil.
+// Inventory rarely changes, while sensor readings should stay close to real time.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"about":                 time.Hour,
+	"chassis info":          time.Hour,
+	"chassis processors":    time.Hour,
+	"chassis memory":        5 * time.Minute,
+	"chassis":               10 * time.Second,
+	"chassis batteries":     time.Minute,
+	"chassis fans":          10 * time.Second,
+	"chassis temps":         10 * time.Second,
+	"chassis volts":         10 * time.Second,
+	"chassis intrusion":     10 * time.Second,
+	"chassis frontpanel":    5 * time.Minute,
+	"chassis nics":          30 * time.Second,
+	"chassis slots":         time.Hour,
+	"chassis bios":          time.Hour,
+	"chassis biossetup":     time.Hour,
+	"chassis pwrmonitoring": 10 * time.Second,
+	"chassis pwrsupplies":   30 * time.Second,
+	"storage controller":    time.Minute,
+	"storage enclosure":     time.Minute,
+	"storage vdisk":         time.Minute,
+	"storage pdisk":         time.Minute,
+}
+
+// CacheConfig configures caching of omreport output.
+type CacheConfig struct {
+	// TTLs maps command families, i.e. omreport subcommands without their 'key=value'
+	// parameters such as "chassis temps" or "storage pdisk", to the amount of time their
+	// output is cached. Defaults to DefaultCacheTTLs when nil.
+	TTLs map[string]time.Duration
+
+	// TTL of command families missing from TTLs. Output of those commands is not cached when zero.
+	DefaultTTL time.Duration
+
+	// Amount of time after an entry expires during which it is still served, marked as stale,
+	// while it is refreshed in the background. Expired entries are never served when zero.
+	StaleWhileRevalidate time.Duration
+}
+
+// cacheEntry is the cached output of a single omreport command.
+type cacheEntry struct {
+	args        []string
+	data        []byte
+	collectedAt time.Time
+	refreshing  bool
+}
+
+// responseCache caches omreport output keyed by command and arguments.
+type responseCache struct {
+	mu      sync.Mutex
+	cfg     CacheConfig
+	entries map[string]*cacheEntry
+}
+
+func newResponseCache(cfg *CacheConfig) *responseCache {
+	c := &responseCache{cfg: *cfg, entries: map[string]*cacheEntry{}}
+	if c.cfg.TTLs == nil {
+		c.cfg.TTLs = DefaultCacheTTLs
+	}
+	return c
+}
+
+// ttl returns the TTL of the command family of args.
+func (c *responseCache) ttl(args []string) time.Duration {
+	if ttl, ok := c.cfg.TTLs[strings.Join(subcommand(args), " ")]; ok {
+		return ttl
+	}
+	return c.cfg.DefaultTTL
+}
+
+// get returns the cached response for args, if any. A stale response is returned if the
+// entry expired within the stale-while-revalidate window, in which case revalidate
+// is true if the caller is responsible for refreshing the entry.
+func (c *responseCache) get(args []string, now time.Time) (resp *response, revalidate bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[callKey(args)]
+	if !ok {
+		return nil, false
+	}
+	age := now.Sub(entry.collectedAt)
+	ttl := c.ttl(args)
+	if age > ttl+c.cfg.StaleWhileRevalidate {
+		return nil, false
+	}
+	resp = &response{
+		data:        append([]byte(nil), entry.data...),
+		collectedAt: entry.collectedAt,
+		stale:       age > ttl,
+	}
+	if resp.stale && !entry.refreshing {
+		entry.refreshing = true
+		revalidate = true
+	}
+	return resp, revalidate
+}
+
+// put caches data as the output of args collected at collectedAt.
+func (c *responseCache) put(args []string, data []byte, collectedAt time.Time) {
+	if c.ttl(args) <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[callKey(args)] = &cacheEntry{
+		args:        append([]string(nil), args...),
+		data:        append([]byte(nil), data...),
+		collectedAt: collectedAt,
+	}
+}
+
+// revalidated marks the entry for args as no longer being refreshed.
+func (c *responseCache) revalidated(args []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[callKey(args)]; ok {
+		entry.refreshing = false
+	}
+}
+
+// remove removes the entry for args, if any.
+func (c *responseCache) remove(args []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, callKey(args))
+}
+
+// invalidate removes every entry whose arguments start with prefix.
+func (c *responseCache) invalidate(prefix []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if hasPrefix(entry.args, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// hasPrefix returns true if args starts with prefix.
+func hasPrefix(args, prefix []string) bool {
+	if len(prefix) > len(args) {
+		return false
+	}
+	for i := range prefix {
+		if args[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
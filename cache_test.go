@@ -0,0 +1,126 @@
+package omreport
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_Cache(t *testing.T) {
+	newCachedReporter := func(cfg *CacheConfig) (*OMReport, *int32) {
+		var calls, maxRunning int32
+		om, err := NewOMReporter(&Config{
+			Executor:    countingExecutor(0, &calls, &maxRunning),
+			Cache:       cfg,
+			OMSAVersion: "8.5.0",
+		})
+		require.NoError(t, err)
+		return om, &calls
+	}
+
+	t.Run("fresh entries are served from cache", func(t *testing.T) {
+		om, calls := newCachedReporter(&CacheConfig{})
+		first, err := om.ChassisTemps()
+		require.NoError(t, err)
+		second, err := om.ChassisTemps()
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		assert.Equal(t, first.CollectedAt, second.CollectedAt)
+		assert.False(t, second.Stale)
+	})
+	t.Run("families without a TTL are not cached", func(t *testing.T) {
+		om, calls := newCachedReporter(&CacheConfig{
+			TTLs: map[string]time.Duration{"chassis temps": time.Hour},
+		})
+		for i := 0; i < 2; i++ {
+			_, err := om.ChassisFans()
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+	t.Run("parameters are part of the cache key", func(t *testing.T) {
+		om, calls := newCachedReporter(&CacheConfig{})
+		_, err := om.StoragePDisk(0)
+		require.NoError(t, err)
+		_, err = om.StoragePDisk(1)
+		require.NoError(t, err)
+		_, err = om.StoragePDisk(0)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+	t.Run("stale while revalidate", func(t *testing.T) {
+		om, calls := newCachedReporter(&CacheConfig{
+			TTLs:                 map[string]time.Duration{"chassis temps": 50 * time.Millisecond},
+			StaleWhileRevalidate: time.Hour,
+		})
+		first, err := om.ChassisTemps()
+		require.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+
+		stale, err := om.ChassisTemps()
+		require.NoError(t, err)
+		assert.True(t, stale.Stale)
+		assert.Equal(t, first.CollectedAt, stale.CollectedAt)
+
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(calls) == 2
+		}, time.Second, 10*time.Millisecond, "stale entry should be refreshed in the background")
+		require.Eventually(t, func() bool {
+			fresh, err := om.ChassisTemps()
+			return err == nil && !fresh.Stale && fresh.CollectedAt.After(first.CollectedAt)
+		}, time.Second, 10*time.Millisecond)
+	})
+	t.Run("expired entries are not served", func(t *testing.T) {
+		om, calls := newCachedReporter(&CacheConfig{
+			TTLs: map[string]time.Duration{"chassis temps": 10 * time.Millisecond},
+		})
+		_, err := om.ChassisTemps()
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		out, err := om.ChassisTemps()
+		require.NoError(t, err)
+		assert.False(t, out.Stale)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+	t.Run("invalidation", func(t *testing.T) {
+		om, calls := newCachedReporter(&CacheConfig{})
+		_, err := om.ChassisTemps()
+		require.NoError(t, err)
+		_, err = om.StorageVDisk()
+		require.NoError(t, err)
+
+		om.InvalidateCache("chassis")
+		_, err = om.ChassisTemps()
+		require.NoError(t, err)
+		_, err = om.StorageVDisk()
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+
+		om.InvalidateCache()
+		_, err = om.StorageVDisk()
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+	})
+	t.Run("in-band errors are not served from cache", func(t *testing.T) {
+		var calls int32
+		om, err := NewOMReporter(&Config{
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				return []byte("<OMA><UserMsg>Invalid controller value.</UserMsg><SMStatus>1006</SMStatus></OMA>"), nil
+			}),
+			Cache:       &CacheConfig{},
+			OMSAVersion: "8.5.0",
+		})
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err := om.StoragePDisk(9)
+			_, ok := err.(*StatusError)
+			require.True(t, ok, "expected a StatusError, got %T", err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
@@ -0,0 +1,107 @@
+package omreport
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// capabilitySkipped are the command families CheckCapabilities never runs, because their
+// output is too large to be gathered only to learn whether they are allowed.
+var capabilitySkipped = map[string]bool{
+	"system esmlog": true,
+}
+
+// CapabilityReport describes which omreport commands can be run with the configured credential.
+type CapabilityReport struct {
+	// UserRights is the OMSA privilege level of the configured credential, as reported by 'omreport about'.
+	UserRights UserRights
+
+	// Allowed lists the commands that succeeded, e.g. "chassis temps".
+	Allowed []string
+
+	// Denied maps the commands that failed because of insufficient privileges to their error.
+	Denied map[string]error
+
+	// Failed maps the commands that failed for any other reason to their error.
+	Failed map[string]error
+
+	// Skipped lists the commands that were not run, e.g. "system esmlog", whose output can be
+	// very large, or "storage pdisk" when no storage controller could be listed.
+	Skipped []string
+}
+
+// CheckCapabilities runs every known omreport command and reports which of them fail because
+// the configured credential lacks the privileges they require. Commands are always run, even if
+// caching is enabled, and are subject to the configured concurrency limit and timeout.
+// 'storage pdisk' is run against the first controller listed by 'storage controller'.
+func (om *OMReport) CheckCapabilities(ctx context.Context) *CapabilityReport {
+	om.init()
+	report := &CapabilityReport{Denied: map[string]error{}, Failed: map[string]error{}}
+
+	var families []string
+	for family := range knownCommands {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+
+	// 'storage controller' sorts before 'storage pdisk', so the controller to list
+	// the physical disks of is known by the time it is needed.
+	controller := -1
+	for _, family := range families {
+		args := strings.Fields(family)
+		switch {
+		case capabilitySkipped[family]:
+			report.Skipped = append(report.Skipped, family)
+			continue
+		case family == "storage pdisk":
+			if controller < 0 {
+				report.Skipped = append(report.Skipped, family)
+				continue
+			}
+			args = append(args, fmt.Sprintf("controller=%d", controller))
+		}
+		data, err := om.run(ctx, args)
+		if err == nil {
+			err = checkStatus(args, data)
+		}
+		switch {
+		case err == nil:
+			report.Allowed = append(report.Allowed, family)
+		case isPermissionError(err):
+			report.Denied[family] = err
+		default:
+			report.Failed[family] = err
+		}
+		if family == "about" && err == nil {
+			about := AboutOutput{}
+			if xml.Unmarshal(data, &about) == nil {
+				report.UserRights = about.UserRights
+			}
+		}
+		if family == "storage controller" && err == nil {
+			controllers := StorageControllerOutput{}
+			if xml.Unmarshal(data, &controllers) == nil && len(controllers.Controllers) > 0 {
+				controller = controllers.Controllers[0].ID
+			}
+		}
+	}
+	return report
+}
+
+// isPermissionError returns true if err reports that omreport lacks the privileges to run a command.
+func isPermissionError(err error) bool {
+	switch err := err.(type) {
+	case *StatusError:
+		return err.Kind == ErrInsufficientRights
+	case *ExecError:
+		stderr := strings.ToLower(err.Stderr)
+		return os.IsPermission(err.Err) ||
+			strings.Contains(stderr, "permission denied") ||
+			strings.Contains(stderr, "insufficient privileges")
+	}
+	return false
+}
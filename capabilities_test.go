@@ -0,0 +1,76 @@
+package omreport
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_CheckCapabilities(t *testing.T) {
+	fixtures := &fixtureExecutor{dir: "testdata"}
+	var pdiskArgs []string
+	om, err := NewOMReporter(&Config{
+		OMSAVersion: "8.5.0",
+		Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			switch strings.Join(args[1:3], " ") {
+			case "storage pdisk":
+				pdiskArgs = args[1:4]
+			case "system esmlog":
+				t.Error("system esmlog should not be run")
+			case "storage vdisk":
+				return []byte("Error! Insufficient privileges to run this command.\n"), nil
+			case "chassis pwrmonitoring":
+				return nil, &ExecError{Argv: args, ExitCode: 1, Stderr: "service not running"}
+			}
+			return fixtures.Execute(ctx, name, args...)
+		}),
+	})
+	require.NoError(t, err)
+
+	report := om.CheckCapabilities(context.Background())
+	assert.Equal(t, UserRightsUser, report.UserRights)
+	assert.Contains(t, report.Allowed, "about")
+	assert.Contains(t, report.Allowed, "chassis temps")
+	assert.Contains(t, report.Allowed, "storage pdisk")
+	assert.Equal(t, []string{"storage", "pdisk", "controller=1"}, pdiskArgs)
+	assert.Len(t, report.Denied, 1)
+	assert.Contains(t, report.Denied, "storage vdisk")
+	assert.Len(t, report.Failed, 1)
+	assert.Contains(t, report.Failed, "chassis pwrmonitoring")
+	assert.Equal(t, []string{"system esmlog"}, report.Skipped)
+	assert.Len(t, report.Allowed, len(knownCommands)-3)
+
+	t.Run("storage pdisk is skipped without a controller", func(t *testing.T) {
+		om, err := NewOMReporter(&Config{
+			OMSAVersion: "8.5.0",
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				switch strings.Join(args[1:3], " ") {
+				case "storage controller":
+					return []byte("Error! Insufficient privileges to run this command.\n"), nil
+				case "storage pdisk":
+					t.Error("storage pdisk should not be run")
+				}
+				return fixtures.Execute(ctx, name, args...)
+			}),
+		})
+		require.NoError(t, err)
+
+		report := om.CheckCapabilities(context.Background())
+		assert.Contains(t, report.Denied, "storage controller")
+		assert.Equal(t, []string{"storage pdisk", "system esmlog"}, report.Skipped)
+	})
+}
+
+func TestCommandExecutor_Credential(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing credentials requires root")
+	}
+	e := &CommandExecutor{Credential: &Credential{UID: 65534, GID: 65534}}
+	out, err := e.Execute(context.Background(), "/bin/sh", "-c", "id -u; id -g; id -G")
+	require.NoError(t, err)
+	assert.Equal(t, "65534\n65534\n65534\n", string(out))
+}
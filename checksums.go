@@ -0,0 +1,93 @@
+package omreport
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// UntrustedBinaryError is returned by NewOMReporter when the sha256 checksum of the binary
+// is not one of the configured trusted checksums.
+type UntrustedBinaryError struct {
+	// Path to the binary.
+	Path string
+
+	// Checksum is the hex encoded sha256 checksum of the binary, which can be added to
+	// the trusted checksums once the binary has been vetted.
+	Checksum string
+}
+
+func (e *UntrustedBinaryError) Error() string {
+	return fmt.Sprintf("sha256 checksum %s of %s is not a trusted checksum", e.Checksum, e.Path)
+}
+
+// trustedChecksums returns the set of hex encoded sha256 checksums the binary is allowed
+// to have according to cfg, or nil if no trusted checksums are configured. Checksums listed
+// in cfg.TrustedChecksums are only trusted for their OMSA version if the version is known.
+func trustedChecksums(cfg *Config, version Version, known bool) (map[string]bool, error) {
+	if cfg.TrustedChecksums == nil && cfg.TrustedChecksumsFile == "" {
+		return nil, nil
+	}
+	trusted := map[string]bool{}
+	add := func(checksum string) error {
+		checksum = strings.ToLower(checksum)
+		if b, err := hex.DecodeString(checksum); err != nil || len(b) != 32 {
+			return fmt.Errorf("invalid sha256 checksum %q", checksum)
+		}
+		trusted[checksum] = true
+		return nil
+	}
+	for v, checksums := range cfg.TrustedChecksums {
+		trustedVersion, err := ParseVersion(v)
+		if err != nil {
+			return nil, err
+		}
+		if known && trustedVersion != version {
+			continue
+		}
+		for _, checksum := range checksums {
+			if err := add(checksum); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cfg.TrustedChecksumsFile != "" {
+		checksums, err := readChecksumsFile(cfg.TrustedChecksumsFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, checksum := range checksums {
+			if err := add(checksum); err != nil {
+				return nil, fmt.Errorf("%s: %v", cfg.TrustedChecksumsFile, err)
+			}
+		}
+	}
+	return trusted, nil
+}
+
+// readChecksumsFile returns the checksums listed in the file at path. Every line starts with a
+// checksum, optionally followed by anything else such as the name of the binary, which is the
+// format written by sha256sum. Empty lines and lines starting with '#' are ignored.
+func readChecksumsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var checksums []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		checksums = append(checksums, strings.Fields(line)[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
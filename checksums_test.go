@@ -0,0 +1,80 @@
+package omreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const omcliproxyChecksum = "9ce4d205ede1d2b1cfc0a2e7fbb4f1adcfd0d8d0370c77bae30c23a12a784054"
+
+func TestNewOMReporter_TrustedChecksums(t *testing.T) {
+	untrusted := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	t.Run("trusted checksum", func(t *testing.T) {
+		_, err := NewOMReporter(&Config{
+			OMCLIProxyPath:   "testdata/omcliproxy",
+			TrustedChecksums: map[string][]string{"8.5.0": {untrusted}, "9.1.0": {omcliproxyChecksum}},
+		})
+		require.NoError(t, err)
+	})
+	t.Run("untrusted checksum", func(t *testing.T) {
+		_, err := NewOMReporter(&Config{
+			OMCLIProxyPath:   "testdata/omcliproxy",
+			TrustedChecksums: map[string][]string{"8.5.0": {untrusted}},
+		})
+		require.Error(t, err)
+		untrustedErr, ok := err.(*UntrustedBinaryError)
+		require.True(t, ok, "expected an UntrustedBinaryError, got %T", err)
+		assert.Equal(t, omcliproxyChecksum, untrustedErr.Checksum)
+		assert.Equal(t, "testdata/omcliproxy", untrustedErr.Path)
+	})
+	t.Run("checksum trusted for another version", func(t *testing.T) {
+		_, err := NewOMReporter(&Config{
+			OMCLIProxyPath:   "testdata/omcliproxy",
+			OMSAVersion:      "8.5.0",
+			TrustedChecksums: map[string][]string{"8.5": {untrusted}, "9.1.0": {omcliproxyChecksum}},
+		})
+		require.Error(t, err)
+		_, ok := err.(*UntrustedBinaryError)
+		require.True(t, ok, "expected an UntrustedBinaryError, got %T", err)
+	})
+	t.Run("invalid checksum", func(t *testing.T) {
+		_, err := NewOMReporter(&Config{
+			OMCLIProxyPath:   "testdata/omcliproxy",
+			TrustedChecksums: map[string][]string{"8.5.0": {"foo"}},
+		})
+		require.Error(t, err)
+	})
+	t.Run("checksums file", func(t *testing.T) {
+		tmpDir, err := ioutil.TempDir(".", "")
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll(tmpDir)
+			require.NoError(t, err)
+		}()
+		path := filepath.Join(tmpDir, "SHA256SUMS")
+		err = ioutil.WriteFile(path, []byte("# OMSA 9.1.0\n\n"+omcliproxyChecksum+"  omcliproxy\n"), 0644)
+		require.NoError(t, err)
+
+		_, err = NewOMReporter(&Config{
+			OMCLIProxyPath:       "testdata/omcliproxy",
+			OMSAVersion:          "8.5.0",
+			TrustedChecksumsFile: path,
+		})
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(path, []byte(untrusted+"\n"), 0644)
+		require.NoError(t, err)
+		_, err = NewOMReporter(&Config{
+			OMCLIProxyPath:       "testdata/omcliproxy",
+			TrustedChecksumsFile: path,
+		})
+		_, ok := err.(*UntrustedBinaryError)
+		require.True(t, ok, "expected an UntrustedBinaryError, got %T", err)
+	})
+}
@@ -0,0 +1,170 @@
+package omreport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// paramType validates the value of a 'key=value' parameter of an omreport command.
+type paramType func(value string) error
+
+// intParam accepts non-negative decimal integers, e.g. the ID of a controller.
+func intParam(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 0 || strconv.Itoa(n) != value {
+		return fmt.Errorf("expected a non-negative integer")
+	}
+	return nil
+}
+
+// idParam accepts colon separated non-negative decimal integers, e.g. the ID '0:1:4'
+// of a physical disk on connector 0 of enclosure 1.
+func idParam(value string) error {
+	for _, part := range strings.Split(value, ":") {
+		if err := intParam(part); err != nil {
+			return fmt.Errorf("expected non-negative integers separated by colons")
+		}
+	}
+	return nil
+}
+
+// knownCommands are the command families supported by this package.
+var knownCommands = map[string]bool{
+	"about":                 true,
+	"chassis":               true,
+	"chassis info":          true,
+	"chassis batteries":     true,
+	"chassis fans":          true,
+	"chassis processors":    true,
+	"chassis memory":        true,
+	"chassis temps":         true,
+	"chassis volts":         true,
+	"chassis intrusion":     true,
+	"chassis frontpanel":    true,
+	"chassis nics":          true,
+	"chassis slots":         true,
+	"chassis bios":          true,
+	"chassis biossetup":     true,
+	"chassis pwrmonitoring": true,
+	"chassis pwrsupplies":   true,
+	"storage controller":    true,
+	"storage enclosure":     true,
+	"storage vdisk":         true,
+	"storage pdisk":         true,
+	"system esmlog":         true,
+}
+
+// commandParams maps command families to the parameters they accept. Every family
+// in knownCommands is a known command, whether or not it accepts parameters.
+var commandParams = map[string]map[string]paramType{
+	"chassis fans":        {"index": intParam},
+	"chassis processors":  {"index": intParam},
+	"chassis memory":      {"index": intParam},
+	"chassis temps":       {"index": intParam},
+	"chassis volts":       {"index": intParam},
+	"chassis nics":        {"index": intParam},
+	"chassis slots":       {"index": intParam},
+	"chassis pwrsupplies": {"index": intParam},
+	"storage controller":  {"controller": intParam},
+	"storage enclosure":   {"controller": intParam, "enclosure": idParam},
+	"storage vdisk":       {"controller": intParam, "vdisk": intParam},
+	"storage pdisk":       {"controller": intParam, "vdisk": intParam, "pdisk": idParam},
+}
+
+// A Command is an omreport command that has been validated against the known subcommands
+// and their parameters, which makes it safe to build from untrusted input.
+type Command struct {
+	args []string
+}
+
+// ParseCommand validates args, e.g. ["storage", "pdisk", "controller=0"], and returns the
+// corresponding Command. args must consist of a known subcommand followed by any number of
+// 'key=value' parameters the subcommand accepts. Returns a *CommandError otherwise.
+func ParseCommand(args ...string) (Command, error) {
+	if err := validateArgs(args); err != nil {
+		return Command{}, err
+	}
+	return Command{args: append([]string(nil), args...)}, nil
+}
+
+// Args returns the arguments of the command, e.g. ["storage", "pdisk", "controller=0"].
+func (c Command) Args() []string {
+	return append([]string(nil), c.args...)
+}
+
+func (c Command) String() string {
+	return strings.Join(c.args, " ")
+}
+
+// A CommandBuilder builds a Command from a subcommand and typed parameters.
+//
+//	cmd, err := NewCommandBuilder("storage", "pdisk").Int("controller", 0).Build()
+type CommandBuilder struct {
+	args []string
+}
+
+// NewCommandBuilder returns a CommandBuilder for the specified subcommand, e.g. "chassis", "temps".
+func NewCommandBuilder(subcommand ...string) *CommandBuilder {
+	return &CommandBuilder{args: append([]string(nil), subcommand...)}
+}
+
+// Int adds the integer parameter key, e.g. 'controller=0'.
+func (b *CommandBuilder) Int(key string, value int) *CommandBuilder {
+	return b.Param(key, strconv.Itoa(value))
+}
+
+// Param adds the parameter key with the specified value, e.g. 'pdisk=0:1:4'.
+func (b *CommandBuilder) Param(key, value string) *CommandBuilder {
+	b.args = append(b.args, key+"="+value)
+	return b
+}
+
+// Build validates the command and returns it. Returns a *CommandError if the subcommand is not
+// known or one of its parameters is not accepted.
+func (b *CommandBuilder) Build() (Command, error) {
+	return ParseCommand(b.args...)
+}
+
+// validateArgs returns a *CommandError unless args is a known subcommand followed by
+// parameters it accepts.
+func validateArgs(args []string) error {
+	var sub []string
+	for len(sub) < len(args) && !strings.Contains(args[len(sub)], "=") {
+		sub = append(sub, args[len(sub)])
+	}
+	family := strings.Join(sub, " ")
+	if !knownCommands[family] {
+		return &CommandError{Args: args, Reason: fmt.Sprintf("unknown subcommand %q", family)}
+	}
+
+	seen := map[string]bool{}
+	for _, arg := range args[len(sub):] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return &CommandError{Args: args, Reason: fmt.Sprintf("expected parameter %q to be key=value", arg)}
+		}
+		key, value := parts[0], parts[1]
+		validate, ok := commandParams[family][key]
+		if !ok {
+			return &CommandError{Args: args, Reason: fmt.Sprintf("unknown parameter %q", key)}
+		}
+		if seen[key] {
+			return &CommandError{Args: args, Reason: fmt.Sprintf("duplicate parameter %q", key)}
+		}
+		seen[key] = true
+		if err := validate(value); err != nil {
+			return &CommandError{Args: args, Reason: fmt.Sprintf("invalid value %q of parameter %q: %v", value, key, err)}
+		}
+	}
+	return nil
+}
+
+// ReportCommand runs the validated omreport command like ReportContext. Returns a *CommandError
+// if cmd was not built by ParseCommand or a CommandBuilder, e.g. the zero Command.
+func (om *OMReport) ReportCommand(ctx context.Context, cmd Command) ([]byte, error) {
+	if err := validateArgs(cmd.args); err != nil {
+		return nil, err
+	}
+	return om.reportRaw(ctx, cmd.args)
+}
@@ -0,0 +1,94 @@
+package omreport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	valid := [][]string{
+		{"about"},
+		{"chassis"},
+		{"chassis", "temps", "index=1"},
+		{"storage", "pdisk", "controller=0"},
+		{"storage", "pdisk", "controller=0", "pdisk=0:1:4"},
+		{"storage", "enclosure", "controller=1", "enclosure=0:1"},
+		{"system", "esmlog"},
+	}
+	for _, args := range valid {
+		cmd, err := ParseCommand(args...)
+		require.NoError(t, err, "%q should be valid", args)
+		assert.Equal(t, args, cmd.Args())
+	}
+
+	invalid := [][]string{
+		{},
+		{"system", "alertlog"},
+		{"storage", "pdisk", "-outc", "/etc/passwd"},
+		{"storage", "pdisk", "controller=0;reboot"},
+		{"storage", "pdisk", "controller=-1"},
+		{"storage", "pdisk", "controller=01"},
+		{"storage", "pdisk", "controller=0", "controller=1"},
+		{"storage", "pdisk", "pdisk=0::4"},
+		{"storage", "vdisk", "pdisk=0"},
+		{"chassis", "info", "index=0"},
+		{"controller=0", "storage", "pdisk"},
+	}
+	for _, args := range invalid {
+		_, err := ParseCommand(args...)
+		require.Error(t, err, "%q should be invalid", args)
+		cmdErr, ok := err.(*CommandError)
+		require.True(t, ok, "expected a CommandError, got %T", err)
+		assert.Equal(t, args, cmdErr.Args)
+	}
+}
+
+func TestCommandBuilder(t *testing.T) {
+	cmd, err := NewCommandBuilder("storage", "pdisk").Int("controller", 0).Param("pdisk", "0:1:4").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "storage pdisk controller=0 pdisk=0:1:4", cmd.String())
+
+	_, err = NewCommandBuilder("storage", "pdisk").Int("controller", -1).Build()
+	require.Error(t, err)
+	_, err = NewCommandBuilder("storage", "pdisk").Param("controller", "0 -outc /etc/passwd").Build()
+	require.Error(t, err)
+}
+
+func TestOMReport_RawArgs(t *testing.T) {
+	t.Run("arguments are validated by default", func(t *testing.T) {
+		om, err := NewOMReporter(&Config{Executor: &fixtureExecutor{dir: "testdata"}})
+		require.NoError(t, err)
+
+		_, err = om.Report("chassis", "temps")
+		require.NoError(t, err)
+		_, err = om.Report("storage", "pdisk", "controller=0", "-outc", "/tmp/out")
+		_, ok := err.(*CommandError)
+		require.True(t, ok, "expected a CommandError, got %T", err)
+
+		cmd, err := ParseCommand("storage", "pdisk", "controller=0")
+		require.NoError(t, err)
+		_, err = om.ReportCommand(context.Background(), cmd)
+		require.NoError(t, err)
+		_, err = om.ReportCommand(context.Background(), Command{})
+		_, ok = err.(*CommandError)
+		require.True(t, ok, "the zero Command should be rejected, got %T", err)
+	})
+	t.Run("raw arguments", func(t *testing.T) {
+		var gotArgs []string
+		om, err := NewOMReporter(&Config{
+			AllowRawArgs: true,
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				gotArgs = args
+				return []byte("<OMA/>"), nil
+			}),
+		})
+		require.NoError(t, err)
+
+		_, err = om.Report("system", "alertlog")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"omreport", "system", "alertlog", "-fmt", "xml"}, gotArgs)
+	})
+}
@@ -0,0 +1,146 @@
+package omreport
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxConcurrency is the default maximum number of omreport commands an OMReport
+// runs at the same time. omcliproxy and the dataeng backend are known to misbehave
+// when queried concurrently, so commands are serialized by default.
+const DefaultMaxConcurrency = 1
+
+// call is an in-flight or completed omreport invocation shared by every caller
+// that requested the same command while it was running.
+type call struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	dups    int
+	waiters int
+	resp    *response
+	err     error
+}
+
+// callGroup coalesces concurrent invocations of identical omreport commands.
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do runs fn unless an invocation for key is already in flight, in which case it waits
+// for that invocation and returns its result instead. Every caller stops waiting when its
+// own ctx is done. The invocation runs in the background under a context that is only
+// cancelled once every caller waiting for it is done, so that a caller with a short
+// deadline never cuts the invocation short for the others.
+func (g *callGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (*response, error)) (*response, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	c, ok := g.calls[key]
+	if ok {
+		c.dups++
+	} else {
+		runCtx, cancel := context.WithCancel(context.Background())
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(runCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return g.result(c)
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	c.waiters--
+	last := c.waiters == 0
+	if last {
+		// Nobody else is interested in the result anymore. Later callers start a new
+		// invocation rather than joining this one, which is being cancelled.
+		c.cancel()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+	}
+	g.mu.Unlock()
+	if !last {
+		return nil, contextError(ctx)
+	}
+	// The last caller gets the result of the cancelled invocation, like it would have
+	// had it run the invocation under its own ctx, e.g. the error of the last attempt.
+	<-c.done
+	resp, err := g.result(c)
+	if err == context.Canceled {
+		// The invocation was cancelled on behalf of ctx, which may have timed out instead.
+		return nil, contextError(ctx)
+	}
+	return resp, err
+}
+
+// result returns the result of the completed invocation c, copied if it is shared.
+func (g *callGroup) result(c *call) (*response, error) {
+	g.mu.Lock()
+	shared := c.dups > 0
+	g.mu.Unlock()
+	if shared {
+		return c.result()
+	}
+	return c.resp, c.err
+}
+
+// run runs fn for the invocation c of key and publishes its result.
+func (g *callGroup) run(ctx context.Context, key string, c *call, fn func(ctx context.Context) (*response, error)) {
+	resp, err := fn(ctx)
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.resp, c.err = resp, err
+	g.mu.Unlock()
+	c.cancel()
+	close(c.done)
+}
+
+// result returns a copy of the result of c, so that callers sharing an invocation
+// cannot observe each other's modifications to the output.
+func (c *call) result() (*response, error) {
+	if c.resp == nil {
+		return nil, c.err
+	}
+	resp := *c.resp
+	resp.data = append([]byte(nil), c.resp.data...)
+	return &resp, c.err
+}
+
+// callKey returns the key identifying the omreport command in args.
+func callKey(args []string) string {
+	return strings.Join(args, "\x00")
+}
+
+// acquire blocks until one of the slots in sem is available or ctx is done.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
+
+// release frees a slot in sem acquired with acquire.
+func release(sem chan struct{}) {
+	<-sem
+}
+
+// contextError returns ErrTimeout if ctx's deadline was exceeded, or ctx.Err() otherwise.
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return ErrTimeout
+	}
+	return ctx.Err()
+}
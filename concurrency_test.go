@@ -0,0 +1,173 @@
+package omreport
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingExecutor returns an Executor serving fixtures that records how many
+// invocations it ran and the highest number of invocations running at once.
+func countingExecutor(delay time.Duration, calls, maxRunning *int32) Executor {
+	var running int32
+	fixtures := &fixtureExecutor{dir: "testdata"}
+	return ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		atomic.AddInt32(calls, 1)
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		return fixtures.Execute(ctx, name, args...)
+	})
+}
+
+func TestOMReport_MaxConcurrency(t *testing.T) {
+	commands := [][]string{
+		{"chassis"}, {"chassis", "fans"}, {"chassis", "temps"}, {"chassis", "memory"},
+		{"storage", "vdisk"}, {"storage", "controller"},
+	}
+	for _, limit := range []int{0, 3} {
+		var calls, maxRunning int32
+		om, err := NewOMReporter(&Config{
+			Executor:       countingExecutor(20*time.Millisecond, &calls, &maxRunning),
+			MaxConcurrency: limit,
+		})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for _, args := range commands {
+			wg.Add(1)
+			go func(args []string) {
+				defer wg.Done()
+				_, err := om.Report(args...)
+				assert.NoError(t, err)
+			}(args)
+		}
+		wg.Wait()
+
+		expected := int32(limit)
+		if limit == 0 {
+			expected = DefaultMaxConcurrency
+		}
+		assert.Equal(t, int32(len(commands)), calls)
+		assert.Equal(t, expected, maxRunning, "commands should run at most %d at a time", expected)
+	}
+}
+
+func TestOMReport_CoalesceIdenticalCommands(t *testing.T) {
+	var calls, maxRunning int32
+	om, err := NewOMReporter(&Config{
+		Executor:    countingExecutor(100*time.Millisecond, &calls, &maxRunning),
+		OMSAVersion: "8.5.0",
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := om.StorageController()
+			assert.NoError(t, err)
+			assert.Len(t, out.Controllers, 2)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls, "simultaneous identical commands should share a single execution")
+}
+
+func TestCallGroup_CallerDeadlines(t *testing.T) {
+	t.Run("joined callers outlive the caller that started the invocation", func(t *testing.T) {
+		g := callGroup{}
+		release := make(chan struct{})
+		started := make(chan struct{})
+		fn := func(ctx context.Context) (*response, error) {
+			close(started)
+			select {
+			case <-release:
+				return &response{data: []byte("<OMA/>")}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		first := make(chan error, 1)
+		go func() {
+			_, err := g.do(ctx, "key", fn)
+			first <- err
+		}()
+		<-started
+		second := make(chan *response, 1)
+		go func() {
+			resp, err := g.do(context.Background(), "key", fn)
+			assert.NoError(t, err)
+			second <- resp
+		}()
+
+		assert.Equal(t, ErrTimeout, <-first)
+		close(release)
+		resp := <-second
+		require.NotNil(t, resp)
+		assert.Equal(t, "<OMA/>", string(resp.data))
+	})
+	t.Run("invocation is cancelled once every caller is gone", func(t *testing.T) {
+		g := callGroup{}
+		cancelled := make(chan struct{})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := g.do(ctx, "key", func(ctx context.Context) (*response, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, contextError(ctx)
+		})
+		assert.Equal(t, ErrTimeout, err)
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("invocation should be cancelled when its only caller is gone")
+		}
+
+		resp, err := g.do(context.Background(), "key", func(ctx context.Context) (*response, error) {
+			return &response{data: []byte("<OMA/>")}, nil
+		})
+		require.NoError(t, err, "later callers should start a new invocation")
+		assert.Equal(t, "<OMA/>", string(resp.data))
+	})
+}
+
+func TestCallGroup_ResultsAreCopied(t *testing.T) {
+	expected, err := ioutil.ReadFile("testdata/omreport-chassis-temps.xml")
+	require.NoError(t, err)
+
+	g := callGroup{}
+	release := make(chan struct{})
+	results := make(chan []byte, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp, _ := g.do(context.Background(), "key", func(ctx context.Context) (*response, error) {
+				<-release
+				return &response{data: append([]byte(nil), expected...)}, nil
+			})
+			results <- resp.data
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	first := <-results
+	first[0] = 'X'
+	assert.Equal(t, expected, <-results)
+}
@@ -0,0 +1,213 @@
+package omreport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxErrorOutput is the maximum number of bytes of command output retained in an ExecError.
+const maxErrorOutput = 512
+
+var (
+	// ErrTimeout is returned when an omreport invocation does not complete before its deadline.
+	ErrTimeout = errors.New("omreport command timed out")
+
+	// ErrVerificationUnsupported is returned by NewOMReporter when binary verification is
+	// requested along with an Executor that does not run the binary through a CommandExecutor.
+	ErrVerificationUnsupported = errors.New("binary verification requires a CommandExecutor")
+
+	// ErrInsufficientRights is the kind of StatusError returned when the user running
+	// omreport lacks the privileges required by the command.
+	ErrInsufficientRights = errors.New("insufficient rights")
+
+	// ErrInvalidController is the kind of StatusError returned when a command refers
+	// to a storage controller that does not exist.
+	ErrInvalidController = errors.New("invalid controller")
+
+	// ErrUnsupportedCommand is the kind of StatusError returned when omreport does not
+	// support the command on this system.
+	ErrUnsupportedCommand = errors.New("unsupported command")
+)
+
+// ExecError is returned when omcliproxy could not be started or exited unsuccessfully.
+type ExecError struct {
+	// Argv is the full command line, starting with the path to the executed binary.
+	Argv []string
+
+	// ExitCode is the exit code of the process, or -1 if the process could not
+	// be started or was terminated by a signal.
+	ExitCode int
+
+	// Stderr is everything the process wrote to its standard error.
+	Stderr string
+
+	// Output is a prefix of everything the process wrote to its standard output,
+	// truncated to a few hundred bytes.
+	Output string
+
+	// Err is the underlying error, e.g. an *os.PathError if the binary is missing
+	// or an *exec.ExitError if it exited unsuccessfully.
+	Err error
+}
+
+func (e *ExecError) Error() string {
+	msg := fmt.Sprintf("%s: %v", strings.Join(e.Argv, " "), e.Err)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + strings.SplitN(stderr, "\n", 2)[0]
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// CommandError is returned when the arguments of an omreport command are not a known subcommand
+// followed by parameters it accepts.
+type CommandError struct {
+	// Args of the rejected omreport command.
+	Args []string
+
+	// Reason the command was rejected.
+	Reason string
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("invalid omreport command %q: %s", strings.Join(e.Args, " "), e.Reason)
+}
+
+// ParseError is returned when the output of an omreport command cannot be decoded,
+// which usually means that the output format changed in the installed OMSA version.
+type ParseError struct {
+	// Args of the omreport command, e.g. ["storage", "pdisk", "controller=0"].
+	Args []string
+
+	// Err is the underlying decoding error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse output of omreport %s: %v", strings.Join(e.Args, " "), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned when omreport reports an error in-band, either through a nonzero
+// SMStatus or through an error message written in place of the requested document.
+type StatusError struct {
+	// Args of the omreport command, e.g. ["storage", "pdisk", "controller=0"].
+	Args []string
+
+	// SMStatus reported by omreport. SMStatusUnsuccessful if only an error message was reported.
+	SMStatus SMStatus
+
+	// Message reported by omreport, if any.
+	Message string
+
+	// Kind is one of ErrInsufficientRights, ErrInvalidController or ErrUnsupportedCommand,
+	// or nil if the error could not be classified.
+	Kind error
+}
+
+func (e *StatusError) Error() string {
+	reason := e.SMStatus.String()
+	if e.Kind != nil {
+		reason = e.Kind.Error()
+	}
+	msg := fmt.Sprintf("omreport %s: %s (SMStatus %d)", strings.Join(e.Args, " "), reason, int(e.SMStatus))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns the kind of the error.
+func (e *StatusError) Unwrap() error {
+	return e.Kind
+}
+
+// errorDocument models the parts of an omreport response that report in-band errors.
+type errorDocument struct {
+	SMStatus []SMStatus `xml:"SMStatus"`
+	Messages []string   `xml:"UserMsg"`
+}
+
+// checkStatus returns a *StatusError if the output of the omreport command in args
+// reports an in-band error, or nil otherwise. Unlike checkPlainStatus, the whole output
+// is parsed, so typed methods only call it once the SMStatus of their decoded Envelope
+// reports an error.
+func checkStatus(args []string, output []byte) error {
+	if err := checkPlainStatus(args, output); err != nil {
+		return err
+	}
+
+	doc := errorDocument{}
+	if err := xml.Unmarshal(output, &doc); err != nil {
+		// Malformed documents are reported as a ParseError by the typed methods.
+		return nil
+	}
+	for _, status := range doc.SMStatus {
+		if status != SMStatusSuccess {
+			return newStatusError(args, status, strings.TrimSpace(strings.Join(doc.Messages, " ")))
+		}
+	}
+	return nil
+}
+
+// checkPlainStatus returns a *StatusError if the output of the omreport command in args
+// is a plain text error message rather than an XML document, or nil otherwise.
+func checkPlainStatus(args []string, output []byte) error {
+	trimmed := bytes.TrimSpace(output)
+	if bytes.HasPrefix(trimmed, []byte("Error!")) {
+		msg := string(bytes.TrimSpace(bytes.TrimPrefix(trimmed, []byte("Error!"))))
+		return newStatusError(args, SMStatusUnsuccessful, msg)
+	}
+	return nil
+}
+
+// newStatusError returns a *StatusError classified by its SMStatus and message.
+func newStatusError(args []string, status SMStatus, msg string) *StatusError {
+	e := &StatusError{Args: args, SMStatus: status, Message: msg}
+	lower := strings.ToLower(msg)
+	switch {
+	case status == SMStatusAccessDenied,
+		strings.Contains(lower, "insufficient privileges"),
+		strings.Contains(lower, "insufficient rights"),
+		strings.Contains(lower, "access denied"):
+		e.Kind = ErrInsufficientRights
+	case status == SMStatusNoSuchDevice && hasParam(args, "controller"),
+		status == SMStatusInvalidParameter && hasParam(args, "controller"),
+		strings.Contains(lower, "invalid controller"):
+		e.Kind = ErrInvalidController
+	case status == SMStatusNotImplemented,
+		strings.Contains(lower, "invalid command"),
+		strings.Contains(lower, "not supported"):
+		e.Kind = ErrUnsupportedCommand
+	}
+	return e
+}
+
+// hasParam returns true if args contains a 'key=value' parameter with the given key.
+func hasParam(args []string, key string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate returns at most the first n bytes of b.
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		return string(b[:n]) + "..."
+	}
+	return string(b)
+}
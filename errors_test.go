@@ -0,0 +1,91 @@
+package omreport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		output   string
+		status   SMStatus
+		kind     error
+		noStatus bool
+	}{
+		{
+			name:     "success",
+			args:     []string{"chassis", "temps"},
+			output:   `<OMA><SMStatus>0</SMStatus></OMA>`,
+			noStatus: true,
+		},
+		{
+			name:     "malformed output",
+			args:     []string{"chassis", "temps"},
+			output:   `<OMA><Chassis>`,
+			noStatus: true,
+		},
+		{
+			name:   "access denied",
+			args:   []string{"chassis", "biossetup"},
+			output: `<OMA><SMStatus strval="ACCESS_DENIED">14</SMStatus></OMA>`,
+			status: SMStatusAccessDenied,
+			kind:   ErrInsufficientRights,
+		},
+		{
+			name:   "not implemented",
+			args:   []string{"chassis", "volts"},
+			output: `<OMA><SMStatus>1</SMStatus></OMA>`,
+			status: SMStatusNotImplemented,
+			kind:   ErrUnsupportedCommand,
+		},
+		{
+			name:   "nonzero status after successful status",
+			args:   []string{"chassis", "memory"},
+			output: `<OMA><SMStatus>0</SMStatus><SMStatus>-1</SMStatus></OMA>`,
+			status: SMStatusUnsuccessful,
+		},
+		{
+			name:   "plain text error message",
+			args:   []string{"chassis", "frobnicate"},
+			output: "Error! Invalid command: frobnicate\n",
+			status: SMStatusUnsuccessful,
+			kind:   ErrUnsupportedCommand,
+		},
+		{
+			name:   "plain text insufficient privileges",
+			args:   []string{"storage", "controller"},
+			output: "Error! User has insufficient privileges to run command: omreport storage controller\n",
+			status: SMStatusUnsuccessful,
+			kind:   ErrInsufficientRights,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStatus(tt.args, []byte(tt.output))
+			if tt.noStatus {
+				require.NoError(t, err)
+				return
+			}
+			statusErr, ok := err.(*StatusError)
+			require.True(t, ok, "expected a StatusError, got %T", err)
+			assert.Equal(t, tt.status, statusErr.SMStatus)
+			assert.Equal(t, tt.kind, statusErr.Unwrap())
+		})
+	}
+}
+
+func TestOMReport_StatusError(t *testing.T) {
+	om, err := NewFixtureReporter("testdata")
+	require.NoError(t, err)
+
+	_, err = om.StoragePDisk(9)
+	statusErr, ok := err.(*StatusError)
+	require.True(t, ok, "expected a StatusError, got %T", err)
+	assert.Equal(t, ErrInvalidController, statusErr.Kind)
+	assert.Equal(t, SMStatusInvalidParameter, statusErr.SMStatus)
+	assert.Equal(t, "Invalid controller value. Read, controller=id, to see valid controller values.", statusErr.Message)
+}
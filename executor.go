@@ -0,0 +1,244 @@
+package omreport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// An Executor runs a program with the provided arguments and returns its output.
+// OMReport delegates every omcliproxy invocation to an Executor, which allows
+// omreport to be run through sudo wrappers, inside containers or against test doubles.
+type Executor interface {
+	Execute(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as an Executor.
+type ExecutorFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// Execute calls f(ctx, name, args...).
+func (f ExecutorFunc) Execute(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return f(ctx, name, args...)
+}
+
+// A StreamExecutor is an Executor that can also provide the output of a program while it runs,
+// which allows large omreport outputs to be decoded without buffering them in memory.
+//
+// ExecuteStream starts the named program and returns its standard output. Closing the returned
+// reader stops the program if it is still running, waits for it to exit and returns an error if it
+// could not be run to completion. Executors that do not implement StreamExecutor are still used
+// to stream output, but the whole output is buffered before it is decoded.
+type StreamExecutor interface {
+	Executor
+	ExecuteStream(ctx context.Context, name string, args ...string) (io.ReadCloser, error)
+}
+
+// DefaultEnv is the environment programs run by a CommandExecutor are given when none is configured.
+// It is deliberately minimal, so that variables such as LD_PRELOAD or proxy settings of the
+// current process do not leak into omcliproxy, which runs with elevated privileges.
+var DefaultEnv = []string{
+	"PATH=" + DefaultOMCLIProxyDir + ":" + DefaultOMReportDir + ":/usr/sbin:/usr/bin:/sbin:/bin",
+	"LANG=C",
+}
+
+// CommandExecutor is the default Executor. It runs programs on the local host
+// using os/exec, each in its own process group on Unix platforms.
+type CommandExecutor struct {
+	// Env is the environment of executed programs as "key=value" strings.
+	// Defaults to DefaultEnv when nil.
+	Env []string
+
+	// Dir is the working directory of executed programs.
+	// Defaults to the working directory of the current process when empty.
+	Dir string
+
+	// Umask, if set, is the file mode creation mask of executed programs. The umask of
+	// the current process is briefly changed while a program is started, which affects
+	// files concurrently created by other goroutines. Only supported on Unix platforms.
+	// Defaults to the umask of the current process when nil.
+	Umask *os.FileMode
+
+	// Credential, if set, is the user and groups executed programs run as.
+	// Only supported on Unix platforms.
+	// Defaults to the user and groups of the current process when nil.
+	Credential *Credential
+}
+
+// Credential identifies the user and groups a program runs as.
+type Credential struct {
+	// UID is the user ID.
+	UID uint32
+
+	// GID is the primary group ID.
+	GID uint32
+
+	// Groups are the supplementary group IDs. Programs run without supplementary groups when empty.
+	Groups []uint32
+}
+
+// Execute runs the named program and returns its standard output.
+// If ctx is done before the program exits, every process in its group is killed.
+// Returns an *ExecError if the program cannot be started or exits unsuccessfully.
+func (e *CommandExecutor) Execute(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return e.execute(ctx, exec.Command(name, args...))
+}
+
+// execute runs cmd like Execute.
+func (e *CommandExecutor) execute(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := e.start(cmd); err != nil {
+		return nil, newExecError(cmd, &stdout, &stderr, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, newExecError(cmd, &stdout, &stderr, err)
+		}
+		return stdout.Bytes(), nil
+	case <-ctx.Done():
+		// omcliproxy forks helpers that would otherwise outlive it and keep
+		// the output pipe open, so signal the whole group rather than the leader.
+		killGroup(cmd)
+		<-done
+		return nil, contextError(ctx)
+	}
+}
+
+// ExecuteStream starts the named program and returns a reader of its standard output.
+// If ctx is done before the program exits, every process in its group is killed.
+// Close returns an *ExecError if the program exits unsuccessfully after its output
+// was read to the end.
+func (e *CommandExecutor) ExecuteStream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	return e.executeStream(ctx, exec.Command(name, args...))
+}
+
+// executeStream starts cmd like ExecuteStream.
+func (e *CommandExecutor) executeStream(ctx context.Context, cmd *exec.Cmd) (io.ReadCloser, error) {
+	s := &commandStream{ctx: ctx, cmd: cmd, done: make(chan struct{})}
+	s.cmd.Stderr = &s.stderr
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return nil, newExecError(s.cmd, &s.prefix, &s.stderr, err)
+	}
+	s.stdout = stdout
+	if err := e.start(s.cmd); err != nil {
+		return nil, newExecError(s.cmd, &s.prefix, &s.stderr, err)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.kill()
+		case <-s.done:
+		}
+	}()
+	return s, nil
+}
+
+// start starts cmd in its own process group with the configured environment,
+// working directory, umask and credential.
+func (e *CommandExecutor) start(cmd *exec.Cmd) error {
+	cmd.Env = e.Env
+	if cmd.Env == nil {
+		cmd.Env = DefaultEnv
+	}
+	cmd.Dir = e.Dir
+	if err := setProcAttr(cmd, e.Credential); err != nil {
+		return err
+	}
+	if e.Umask == nil {
+		return cmd.Start()
+	}
+	return startWithUmask(cmd, *e.Umask)
+}
+
+// commandStream is the standard output of a program started by CommandExecutor.ExecuteStream.
+type commandStream struct {
+	ctx    context.Context
+	cmd    *exec.Cmd
+	stdout io.Reader
+	stderr bytes.Buffer
+	prefix bytes.Buffer
+	eof    bool
+	done   chan struct{}
+
+	mu     sync.Mutex
+	killed bool
+}
+
+func (s *commandStream) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+	if room := maxErrorOutput - s.prefix.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		s.prefix.Write(p[:room])
+	}
+	if err == io.EOF {
+		s.eof = true
+	}
+	return n, err
+}
+
+// Close stops the program unless its output was read to the end and waits for it to exit.
+func (s *commandStream) Close() error {
+	close(s.done)
+	if !s.eof {
+		// The program would block writing output nobody reads, so stop it.
+		s.kill()
+	}
+	err := s.cmd.Wait()
+	if s.ctx.Err() != nil {
+		return contextError(s.ctx)
+	}
+	s.mu.Lock()
+	killed := s.killed
+	s.mu.Unlock()
+	if err != nil && !killed {
+		return newExecError(s.cmd, &s.prefix, &s.stderr, err)
+	}
+	return nil
+}
+
+// kill kills every process in the group of the program.
+func (s *commandStream) kill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.killed {
+		s.killed = true
+		killGroup(s.cmd)
+	}
+}
+
+// newExecError returns an *ExecError describing the failed execution of cmd.
+func newExecError(cmd *exec.Cmd, stdout, stderr *bytes.Buffer, err error) *ExecError {
+	return &ExecError{
+		Argv:     cmd.Args,
+		ExitCode: exitCode(err),
+		Stderr:   stderr.String(),
+		Output:   truncate(stdout.Bytes(), maxErrorOutput),
+		Err:      err,
+	}
+}
+
+// exitCode returns the exit code of a process from the error returned when waiting for it,
+// or -1 if it could not be determined.
+func exitCode(err error) int {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return -1
+}
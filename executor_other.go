@@ -0,0 +1,30 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package omreport
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// setProcAttr only supports running programs as the current user on this platform.
+// Programs are not started in their own process group.
+func setProcAttr(cmd *exec.Cmd, c *Credential) error {
+	if c != nil {
+		return errors.New("running programs under a credential is not supported on this platform")
+	}
+	return nil
+}
+
+// startWithUmask is only supported on Unix platforms.
+func startWithUmask(cmd *exec.Cmd, mask os.FileMode) error {
+	return errors.New("setting the umask of programs is not supported on this platform")
+}
+
+// killGroup kills cmd. Processes it started are not killed, since there are no process groups
+// on this platform.
+func killGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,130 @@
+package omreport
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_Executor(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-temps.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	var gotName string
+	var gotArgs []string
+	executor := ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotName, gotArgs = name, args
+		return data, nil
+	})
+	om, err := NewOMReporter(&Config{
+		OMCLIProxyPath: "/usr/bin/sudo",
+		Executor:       executor,
+	})
+	require.NoError(t, err, "binary checks should be skipped for custom executors")
+	require.NoError(t, om.SuspiciousOMCLIProxyBinary())
+
+	for _, cfg := range []*Config{
+		{EnhancedSecurityMode: true},
+		{StrictPermissions: true},
+		{TrustedChecksums: map[string][]string{"8.5.0": {omcliproxyChecksum}}},
+		{TrustedChecksumsFile: "testdata/checksums"},
+	} {
+		cfg.Executor = executor
+		_, err := NewOMReporter(cfg)
+		assert.Equal(t, ErrVerificationUnsupported, err, "verification cannot be requested for custom executors")
+	}
+
+	out, err := om.ChassisTemps()
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/sudo", gotName)
+	assert.Equal(t, []string{"omreport", "chassis", "temps", "-fmt", "xml"}, gotArgs)
+	require.Len(t, out.Probes, 1)
+	assert.Equal(t, "System Board Inlet Temp", out.Probes[0].Location)
+}
+
+func TestCommandExecutor_Execute(t *testing.T) {
+	e := &CommandExecutor{}
+
+	t.Run("stdout only", func(t *testing.T) {
+		out, err := e.Execute(context.Background(), "/bin/sh", "-c", "echo '<OMA/>'; echo noise >&2")
+		require.NoError(t, err)
+		assert.Equal(t, "<OMA/>\n", string(out))
+	})
+	t.Run("missing binary", func(t *testing.T) {
+		_, err := e.Execute(context.Background(), "testdata/nonexistent/omcliproxy", "omreport")
+		execErr, ok := err.(*ExecError)
+		require.True(t, ok, "expected an ExecError, got %T", err)
+		assert.Equal(t, -1, execErr.ExitCode)
+		assert.True(t, os.IsNotExist(execErr.Unwrap()))
+	})
+	t.Run("unsuccessful exit", func(t *testing.T) {
+		_, err := e.Execute(context.Background(), "/bin/sh", "-c", "echo partial; echo service not running >&2; exit 3")
+		execErr, ok := err.(*ExecError)
+		require.True(t, ok, "expected an ExecError, got %T", err)
+		assert.Equal(t, []string{"/bin/sh", "-c", "echo partial; echo service not running >&2; exit 3"}, execErr.Argv)
+		assert.Equal(t, 3, execErr.ExitCode)
+		assert.Equal(t, "service not running\n", execErr.Stderr)
+		assert.Equal(t, "partial\n", execErr.Output)
+		assert.Contains(t, execErr.Error(), "service not running")
+	})
+	t.Run("default environment", func(t *testing.T) {
+		require.NoError(t, os.Setenv("LD_PRELOAD", "/tmp/evil.so"))
+		defer os.Unsetenv("LD_PRELOAD")
+		out, err := e.Execute(context.Background(), "/bin/sh", "-c", "env | sort")
+		require.NoError(t, err)
+		assert.NotContains(t, string(out), "LD_PRELOAD")
+		assert.Contains(t, string(out), "LANG=C\n")
+		assert.Contains(t, string(out), DefaultEnv[0]+"\n")
+	})
+	t.Run("working directory and umask", func(t *testing.T) {
+		umask := os.FileMode(0077)
+		e := &CommandExecutor{Dir: "/", Umask: &umask}
+		out, err := e.Execute(context.Background(), "/bin/sh", "-c", "pwd; umask")
+		require.NoError(t, err)
+		assert.Equal(t, "/\n0077\n", string(out))
+	})
+}
+
+func TestNewOMReporter_Environment(t *testing.T) {
+	require.NoError(t, os.Setenv("OMREPORT_TEST_PASSED", "passed"))
+	defer os.Unsetenv("OMREPORT_TEST_PASSED")
+	require.NoError(t, os.Setenv("OMREPORT_TEST_NOT_PASSED", "not passed"))
+	defer os.Unsetenv("OMREPORT_TEST_NOT_PASSED")
+
+	om, err := NewOMReporter(&Config{
+		OMCLIProxyPath: "testdata/omcliproxy",
+		PassEnv:        []string{"OMREPORT_TEST_PASSED", "OMREPORT_TEST_UNSET"},
+		Env:            []string{"LANG=en_US.UTF-8", "TZ=UTC"},
+	})
+	require.NoError(t, err)
+	e, ok := om.executor().(*CommandExecutor)
+	require.True(t, ok, "expected a CommandExecutor, got %T", om.executor())
+
+	out, err := e.Execute(context.Background(), "/bin/sh", "-c", "env | sort")
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "OMREPORT_TEST_PASSED=passed\n")
+	assert.NotContains(t, string(out), "OMREPORT_TEST_NOT_PASSED")
+	assert.NotContains(t, string(out), "OMREPORT_TEST_UNSET")
+	assert.Contains(t, string(out), "LANG=en_US.UTF-8\n")
+	assert.NotContains(t, string(out), "LANG=C")
+	assert.Contains(t, string(out), "TZ=UTC\n")
+}
+
+func TestOMReport_ParseError(t *testing.T) {
+	om, err := NewOMReporter(&Config{
+		Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte("<OMA><Chassis>"), nil
+		}),
+	})
+	require.NoError(t, err)
+
+	_, err = om.ChassisFans()
+	parseErr, ok := err.(*ParseError)
+	require.True(t, ok, "expected a ParseError, got %T", err)
+	assert.Equal(t, []string{"chassis", "fans"}, parseErr.Args)
+	assert.NotNil(t, parseErr.Unwrap())
+}
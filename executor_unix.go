@@ -0,0 +1,38 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package omreport
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes changes to the umask of the current process, which is inherited by
+// programs run with a configured umask.
+var umaskMu sync.Mutex
+
+// setProcAttr makes cmd start in its own process group, as the user and groups of c if set.
+func setProcAttr(cmd *exec.Cmd, c *Credential) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if c != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: c.UID, Gid: c.GID, Groups: c.Groups}
+	}
+	return nil
+}
+
+// startWithUmask starts cmd with the file mode creation mask set to mask.
+func startWithUmask(cmd *exec.Cmd, mask os.FileMode) error {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	old := syscall.Umask(int(mask))
+	defer syscall.Umask(old)
+	return cmd.Start()
+}
+
+// killGroup kills every process in the group of cmd, which was started by setProcAttr.
+func killGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,103 @@
+package omreport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Firmware models a firmware listed by FirmwareInventory. ID identifies the component among
+// those of the same type, e.g. "0" for controller 0 or "0:3" for enclosure 3 of controller 0,
+// and is empty for components of which there is only one.
+type Firmware struct {
+	Type    FirmwareType
+	ID      string
+	Name    string
+	Version string
+}
+
+// FirmwareInventoryOutput models the firmware of every component reported by omreport.
+// Firmware gathered from sources that could not be collected is missing, and the error
+// encountered is recorded in Errors.
+type FirmwareInventoryOutput struct {
+	Firmware []Firmware
+
+	// Errors holds the errors encountered while collecting the inventory, keyed by omreport command,
+	// e.g. "storage enclosure".
+	Errors map[string]error
+}
+
+// FirmwareInventory returns the firmware of the BIOS, iDRAC, Lifecycle Controller, storage
+// controllers and storage enclosures, gathered from 'omreport chassis bios', 'omreport chassis info',
+// 'omreport storage controller' and 'omreport storage enclosure'. Errors are recorded per command
+// in the output rather than aborting the inventory; an error is returned only if every command failed.
+func (om *OMReport) FirmwareInventory() (*FirmwareInventoryOutput, error) {
+	return om.FirmwareInventoryContext(context.Background())
+}
+
+// FirmwareInventoryContext is like FirmwareInventory but honors the deadline and cancellation of ctx.
+func (om *OMReport) FirmwareInventoryContext(ctx context.Context) (*FirmwareInventoryOutput, error) {
+	out := &FirmwareInventoryOutput{Errors: map[string]error{}}
+
+	if bios, err := om.ChassisBIOSContext(ctx); err != nil {
+		out.Errors["chassis bios"] = err
+	} else {
+		out.Firmware = append(out.Firmware, Firmware{Type: FirmwareTypeBIOS, Name: "BIOS", Version: bios.Version})
+	}
+
+	if info, err := om.ChassisInfoContext(ctx); err != nil {
+		out.Errors["chassis info"] = err
+	} else {
+		for _, chassis := range info.ChassisList {
+			for _, fw := range chassis.FirmwareList {
+				out.Firmware = append(out.Firmware, Firmware{Type: chassisFirmwareType(fw.Name), Name: fw.Name, Version: fw.Version})
+			}
+		}
+	}
+
+	if controllers, err := om.StorageControllerContext(ctx); err != nil {
+		out.Errors["storage controller"] = err
+	} else {
+		for _, c := range controllers.Controllers {
+			out.Firmware = append(out.Firmware, Firmware{
+				Type:    FirmwareTypeStorageController,
+				ID:      strconv.Itoa(c.ID),
+				Name:    c.Name,
+				Version: c.FirmwareVersion,
+			})
+		}
+	}
+
+	if enclosures, err := om.StorageEnclosureContext(ctx); err != nil {
+		out.Errors["storage enclosure"] = err
+	} else {
+		for _, e := range enclosures.Enclosures {
+			out.Firmware = append(out.Firmware, Firmware{
+				Type:    FirmwareTypeStorageEnclosure,
+				ID:      fmt.Sprintf("%d:%d", e.ControllerID, e.ID),
+				Name:    fmt.Sprintf("Enclosure %d", e.ID),
+				Version: e.FirmwareVersion,
+			})
+		}
+	}
+
+	// Every one of the four commands failed, so there is no inventory to return.
+	if len(out.Errors) == 4 {
+		return nil, out.Errors["chassis bios"]
+	}
+	return out, nil
+}
+
+// chassisFirmwareType returns the type of a firmware listed by 'omreport chassis info' from its name,
+// e.g. "iDRAC8" or "Lifecycle Controller".
+func chassisFirmwareType(name string) FirmwareType {
+	switch {
+	case strings.HasPrefix(name, "iDRAC"):
+		return FirmwareTypeIDRAC
+	case strings.HasPrefix(name, "Lifecycle Controller"):
+		return FirmwareTypeLifecycleController
+	default:
+		return FirmwareTypeOther
+	}
+}
@@ -0,0 +1,60 @@
+package omreport
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_FirmwareInventory(t *testing.T) {
+	om, err := NewOMReporter(&Config{Executor: &fixtureExecutor{dir: "testdata"}})
+	require.NoError(t, err)
+
+	out, err := om.FirmwareInventory()
+	require.NoError(t, err)
+	assert.Equal(t, []Firmware{
+		{Type: FirmwareTypeBIOS, Name: "BIOS", Version: "2.13.0"},
+		{Type: FirmwareTypeIDRAC, Name: "iDRAC8", Version: "2.41.40.40 (Build 7)"},
+		{Type: FirmwareTypeLifecycleController, Name: "Lifecycle Controller", Version: "2.41.40.40"},
+		{Type: FirmwareTypeStorageController, ID: "1", Name: "PERC H710P Mini", Version: "21.3.4-0001"},
+		{Type: FirmwareTypeStorageController, ID: "0", Name: "PERC H810 Adapter", Version: "21.3.4-0001"},
+		{Type: FirmwareTypeStorageEnclosure, ID: "0:3", Name: "Enclosure 3", Version: "3.31"},
+	}, out.Firmware)
+
+	t.Run("errors are recorded per command", func(t *testing.T) {
+		fixtures := &fixtureExecutor{dir: "testdata"}
+		om, err := NewOMReporter(&Config{
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				if strings.Join(args[1:3], " ") == "storage enclosure" {
+					return []byte("Error! Insufficient privileges to run this command.\n"), nil
+				}
+				return fixtures.Execute(ctx, name, args...)
+			}),
+		})
+		require.NoError(t, err)
+
+		out, err := om.FirmwareInventory()
+		require.NoError(t, err)
+		assert.Len(t, out.Firmware, 5)
+		require.Len(t, out.Errors, 1)
+		_, ok := out.Errors["storage enclosure"].(*StatusError)
+		require.True(t, ok, "expected a StatusError, got %T", out.Errors["storage enclosure"])
+	})
+
+	t.Run("an error is returned if every command fails", func(t *testing.T) {
+		om, err := NewOMReporter(&Config{
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				return []byte("Error! Insufficient privileges to run this command.\n"), nil
+			}),
+		})
+		require.NoError(t, err)
+
+		out, err := om.FirmwareInventory()
+		assert.Nil(t, out)
+		_, ok := err.(*StatusError)
+		require.True(t, ok, "expected a StatusError, got %T", err)
+	})
+}
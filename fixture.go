@@ -0,0 +1,130 @@
+package omreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NotRecordedError is returned by a FixtureReporter when no fixture has been
+// recorded for the requested omreport command.
+type NotRecordedError struct {
+	// Args of the omreport command, e.g. ["storage", "pdisk", "controller=0"].
+	Args []string
+
+	// Path of the fixture file that was expected to exist.
+	Path string
+}
+
+func (e *NotRecordedError) Error() string {
+	return fmt.Sprintf("omreport %s: not recorded (expected fixture %s)", strings.Join(e.Args, " "), e.Path)
+}
+
+// FixtureReporter implements OMReporter by serving recorded omreport XML from a directory
+// instead of executing omcliproxy. Each command maps to a file laid out like the files in
+// testdata, e.g. 'chassis temps' is served from omreport-chassis-temps.xml and
+// 'storage pdisk controller=0' is served from omreport-storage-pdisk-controller=0.xml,
+// falling back to omreport-storage-pdisk.xml when no controller specific fixture exists.
+type FixtureReporter struct {
+	*OMReport
+}
+
+// NewFixtureReporter returns a FixtureReporter serving fixtures from dir.
+// Report accepts arbitrary arguments, since fixtures are read from files and never executed.
+// Returns an error if dir is not a directory.
+func NewFixtureReporter(dir string) (*FixtureReporter, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("expected %s to be a directory", dir)
+	}
+	om := &OMReport{exec: &fixtureExecutor{dir: dir}, allowRawArgs: true}
+	return &FixtureReporter{OMReport: om}, nil
+}
+
+// fixtureExecutor is an Executor that reads omreport output from fixture files.
+type fixtureExecutor struct {
+	dir string
+}
+
+// Execute returns the contents of the fixture recorded for the omreport command in args.
+// Failed invocations recorded by a Recorder are replayed as an *ExecError.
+func (e *fixtureExecutor) Execute(ctx context.Context, name string, args ...string) ([]byte, error) {
+	argv := append([]string{name}, args...)
+	args = omreportArgs(args)
+	path := filepath.Join(e.dir, fixtureName(args))
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		if inv, ok := e.failure(path); ok {
+			return nil, &ExecError{Argv: argv, ExitCode: inv.ExitStatus, Err: errors.New(inv.Error)}
+		}
+	}
+	if os.IsNotExist(err) && hasParams(args) {
+		data, err = ioutil.ReadFile(filepath.Join(e.dir, fixtureName(subcommand(args))))
+	}
+	if os.IsNotExist(err) {
+		return nil, &NotRecordedError{Args: args, Path: path}
+	}
+	return data, err
+}
+
+// failure returns the failed invocation recorded alongside the missing fixture at path, if any.
+func (e *fixtureExecutor) failure(path string) (Invocation, bool) {
+	meta, err := ioutil.ReadFile(metadataName(path))
+	if err != nil {
+		return Invocation{}, false
+	}
+	inv := Invocation{}
+	if err := json.Unmarshal(meta, &inv); err != nil || inv.Error == "" {
+		return Invocation{}, false
+	}
+	return inv, true
+}
+
+// omreportArgs strips the omreport command name and output format flags that OMReport
+// adds to every invocation, leaving only the subcommand and its parameters.
+func omreportArgs(args []string) []string {
+	if len(args) > 0 && args[0] == DefaultOMReportCommandName {
+		args = args[1:]
+	}
+	if n := len(args); n >= 2 && args[n-2] == "-fmt" {
+		args = args[:n-2]
+	}
+	return args
+}
+
+// subcommand returns args without any 'key=value' parameters.
+func subcommand(args []string) []string {
+	var sub []string
+	for _, arg := range args {
+		if !strings.Contains(arg, "=") {
+			sub = append(sub, arg)
+		}
+	}
+	return sub
+}
+
+// hasParams returns true if args contains any 'key=value' parameters.
+func hasParams(args []string) bool {
+	return len(subcommand(args)) != len(args)
+}
+
+// fixtureName returns the name of the fixture file for the omreport command in args.
+func fixtureName(args []string) string {
+	parts := append([]string{DefaultOMReportCommandName}, args...)
+	name := strings.Join(parts, "-") + ".xml"
+	// Arguments must never be able to escape the fixture directory.
+	return strings.Replace(name, string(filepath.Separator), "_", -1)
+}
+
+// metadataName returns the name of the file describing the invocation recorded in the fixture name.
+func metadataName(name string) string {
+	return strings.TrimSuffix(name, ".xml") + ".json"
+}
@@ -0,0 +1,47 @@
+package omreport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFixtureReporter(t *testing.T) {
+	_, err := NewFixtureReporter("testdata/omcliproxy")
+	require.Error(t, err, "fixture directory must be a directory")
+
+	_, err = NewFixtureReporter("testdata/nonexistent")
+	require.Error(t, err, "fixture directory must exist")
+}
+
+func TestFixtureReporter(t *testing.T) {
+	var _ OMReporter = &FixtureReporter{}
+
+	om, err := NewFixtureReporter("testdata")
+	require.NoError(t, err)
+	require.NoError(t, om.SuspiciousOMCLIProxyBinary())
+
+	t.Run("recorded subcommand", func(t *testing.T) {
+		out, err := om.ChassisTemps()
+		require.NoError(t, err)
+		require.Len(t, out.Probes, 1)
+		assert.Equal(t, "System Board Inlet Temp", out.Probes[0].Location)
+	})
+	t.Run("parameters fall back to subcommand fixture", func(t *testing.T) {
+		out, err := om.StoragePDisk(0)
+		require.NoError(t, err)
+		assert.Len(t, out.PDisks, 3)
+	})
+	t.Run("not recorded subcommand", func(t *testing.T) {
+		_, err := om.Report("system", "alertlog")
+		require.Error(t, err)
+		notRecorded, ok := err.(*NotRecordedError)
+		require.True(t, ok, "expected a NotRecordedError, got %T", err)
+		assert.Equal(t, []string{"system", "alertlog"}, notRecorded.Args)
+		assert.Equal(t, "testdata/omreport-system-alertlog.xml", notRecorded.Path)
+	})
+	t.Run("arguments cannot escape fixture directory", func(t *testing.T) {
+		assert.Equal(t, "omreport-.._.._etc_passwd.xml", fixtureName([]string{"../../etc/passwd"}))
+	})
+}
@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+package omreport
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfdCreateTraps are the numbers of the memfd_create system call, which the syscall
+// package does not define on every architecture.
+var memfdCreateTraps = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	mfdExec         = 0x10
+
+	fAddSeals   = 1033
+	fSealSeal   = 0x1
+	fSealShrink = 0x2
+	fSealGrow   = 0x4
+	fSealWrite  = 0x8
+)
+
+// createMemfd returns an anonymous, executable in-memory file that can be sealed with sealMemfd.
+// The file is closed on exec. Returns syscall.ENOSYS if the kernel does not support memfd_create.
+func createMemfd(name string) (*os.File, error) {
+	trap, ok := memfdCreateTraps[runtime.GOARCH]
+	if !ok {
+		return nil, syscall.ENOSYS
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	// Kernels that restrict executable memfds require MFD_EXEC, which older kernels reject.
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(p)), mfdCloexec|mfdAllowSealing|mfdExec, 0)
+	if errno == syscall.EINVAL {
+		fd, _, errno = syscall.Syscall(trap, uintptr(unsafe.Pointer(p)), mfdCloexec|mfdAllowSealing, 0)
+	}
+	if errno != 0 {
+		return nil, errno
+	}
+	return os.NewFile(fd, "memfd:"+name), nil
+}
+
+// sealMemfd prevents any further change to the contents of f, which was created by createMemfd.
+func sealMemfd(f *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fAddSeals, fSealSeal|fSealShrink|fSealGrow|fSealWrite)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
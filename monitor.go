@@ -0,0 +1,152 @@
+package omreport
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultMonitorInterval is the default interval between verifications of the binary by a Monitor.
+const DefaultMonitorInterval = time.Minute
+
+// ErrLocked is returned instead of running omreport commands or serving their cached output
+// while an OMReport is locked because a Monitor detected that its binary was tampered with.
+var ErrLocked = errors.New("omreport is locked because its binary failed verification")
+
+// MonitorConfig configures a Monitor.
+type MonitorConfig struct {
+	// Interval between verifications of the binary. Defaults to DefaultMonitorInterval when zero.
+	// On Linux, the binary is also verified whenever inotify reports a change to its directory.
+	Interval time.Duration
+
+	// OnTamper, if set, is called with the verification error when tampering is detected.
+	OnTamper func(error)
+
+	// Tampered, if set, receives the verification error when tampering is detected.
+	// The error is dropped if the channel is not ready to receive it.
+	Tampered chan<- error
+
+	// Whether or not to lock the OMReport when tampering is detected. A locked OMReport
+	// returns ErrLocked instead of running any command until ResetLock is called. The OMReport
+	// is locked again whenever the binary fails verification while it is unlocked.
+	Lock bool
+}
+
+// A Monitor verifies the binary of an OMReport in the background, like SuspiciousOMCLIProxyBinary,
+// and reports when it detects tampering. Tampering is reported once when the binary starts failing
+// verification, and again only if the binary passes verification in between.
+type Monitor struct {
+	om       *OMReport
+	cfg      MonitorConfig
+	tampered bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Monitor starts verifying the binary in the background, once right away and then on every
+// interval and filesystem change. Call Stop on the returned Monitor to stop verifying it.
+func (om *OMReport) Monitor(cfg *MonitorConfig) *Monitor {
+	m := &Monitor{
+		om:   om,
+		cfg:  *cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if m.cfg.Interval <= 0 {
+		m.cfg.Interval = DefaultMonitorInterval
+	}
+	go m.run()
+	return m
+}
+
+// Stop stops the monitor and waits for any verification in progress to complete.
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+}
+
+func (m *Monitor) run() {
+	defer close(m.done)
+
+	// Filesystem changes are only watched for binaries that are verified at all. Failing to
+	// watch them is not fatal, since the binary is still verified on every interval.
+	changes := make(chan struct{}, 1)
+	if m.om.verifiesBinary() {
+		m.om.init()
+		if stop, err := watchBinary(m.om.binaryPath(), changes); err == nil {
+			defer stop()
+		}
+	}
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		m.verify()
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+// verify verifies the binary and reports tampering if it newly fails verification.
+// The OMReport is locked on every failed verification, since it may have been unlocked
+// by ResetLock since tampering was reported.
+func (m *Monitor) verify() {
+	err := m.om.SuspiciousOMCLIProxyBinary()
+	if err == nil {
+		m.tampered = false
+		return
+	}
+	if m.cfg.Lock {
+		m.om.lock(err)
+	}
+	if m.tampered {
+		return
+	}
+	m.tampered = true
+	if m.cfg.OnTamper != nil {
+		m.cfg.OnTamper(err)
+	}
+	if m.cfg.Tampered != nil {
+		select {
+		case m.cfg.Tampered <- err:
+		default:
+		}
+	}
+}
+
+// lock locks om because its binary failed verification with err.
+func (om *OMReport) lock(err error) {
+	om.lockMu.Lock()
+	defer om.lockMu.Unlock()
+	if om.lockErr == nil {
+		om.lockErr = err
+	}
+}
+
+// Locked returns the verification error that caused om to be locked, or nil if om is not locked.
+func (om *OMReport) Locked() error {
+	om.lockMu.Lock()
+	defer om.lockMu.Unlock()
+	return om.lockErr
+}
+
+// ResetLock verifies the binary like SuspiciousOMCLIProxyBinary and unlocks om if it passes,
+// so that it runs commands again. Returns the verification error otherwise, in which case om
+// stays locked.
+func (om *OMReport) ResetLock() error {
+	if err := om.SuspiciousOMCLIProxyBinary(); err != nil {
+		return err
+	}
+	om.lockMu.Lock()
+	defer om.lockMu.Unlock()
+	om.lockErr = nil
+	return nil
+}
@@ -0,0 +1,49 @@
+//go:build linux
+// +build linux
+
+package omreport
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// watchEvents are the inotify events on the directory of the binary that cause it to be verified.
+// Watching the directory rather than the binary itself also catches the binary being replaced.
+const watchEvents = syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MODIFY | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// watchBinary sends on changes whenever inotify reports a change to the directory of the binary at
+// path, without blocking. Returns a function that stops watching.
+func watchBinary(path string, changes chan<- struct{}) (func(), error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), watchEvents); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	// Files created from non-blocking descriptors use the runtime poller, so closing f
+	// interrupts a pending Read.
+	f := os.NewFile(uintptr(fd), "inotify")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 4096)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return func() {
+		f.Close()
+		<-done
+	}, nil
+}
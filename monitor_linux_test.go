@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package omreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_Monitor_inotify(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binaryPath := filepath.Join(tmpDir, "omcliproxy")
+	err = ioutil.WriteFile(binaryPath, []byte("foo"), 0755)
+	require.NoError(t, err)
+
+	om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath})
+	require.NoError(t, err)
+
+	tampered := make(chan error, 1)
+	m := om.Monitor(&MonitorConfig{Interval: time.Hour, Tampered: tampered})
+	defer m.Stop()
+	// Give the monitor time to complete its initial verification and start watching.
+	time.Sleep(100 * time.Millisecond)
+
+	replacement := filepath.Join(tmpDir, "replacement")
+	err = ioutil.WriteFile(replacement, []byte("bar"), 0755)
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(replacement, binaryPath))
+	select {
+	case err := <-tampered:
+		require.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("replacing the binary should be detected without waiting for the interval")
+	}
+}
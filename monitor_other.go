@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package omreport
+
+import "errors"
+
+// watchBinary is only supported on Linux. Monitors verify the binary on every interval instead.
+func watchBinary(path string, changes chan<- struct{}) (func(), error) {
+	return nil, errors.New("watching the binary for changes is not supported on this platform")
+}
@@ -0,0 +1,76 @@
+package omreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_Monitor(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binaryPath := filepath.Join(tmpDir, "omcliproxy")
+	original := []byte("#!/bin/sh\necho '<OMA/>'\n")
+	err = ioutil.WriteFile(binaryPath, original, 0755)
+	require.NoError(t, err)
+
+	om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath, OMSAVersion: "8.5.0"})
+	require.NoError(t, err)
+
+	tampered := make(chan error, 1)
+	var callbackErr error
+	m := om.Monitor(&MonitorConfig{
+		Interval: 10 * time.Millisecond,
+		OnTamper: func(err error) { callbackErr = err },
+		Tampered: tampered,
+		Lock:     true,
+	})
+	defer m.Stop()
+	require.NoError(t, om.Locked())
+	_, err = om.Report("chassis")
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho tampered\n"), 0755)
+	require.NoError(t, err)
+	select {
+	case err := <-tampered:
+		require.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("tampering was not detected")
+	}
+	m.Stop()
+	assert.Error(t, callbackErr, "callback should be called when tampering is detected")
+	assert.Error(t, om.Locked())
+
+	_, err = om.Report("chassis")
+	assert.Equal(t, ErrLocked, err)
+
+	require.Error(t, om.ResetLock(), "a binary that still fails verification should not be unlocked")
+	assert.Error(t, om.Locked())
+	_, err = om.Report("chassis")
+	assert.Equal(t, ErrLocked, err)
+
+	err = ioutil.WriteFile(binaryPath, original, 0755)
+	require.NoError(t, err)
+	require.NoError(t, om.ResetLock())
+	require.NoError(t, om.Locked())
+	_, err = om.Report("chassis")
+	require.NoError(t, err)
+
+	t.Run("relocked while the binary still fails verification", func(t *testing.T) {
+		m := &Monitor{om: om, cfg: MonitorConfig{Lock: true}, tampered: true}
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho tampered\n"), 0755)
+		require.NoError(t, err)
+		m.verify()
+		assert.Error(t, om.Locked())
+	})
+}
@@ -2,14 +2,16 @@ package omreport
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
-	"encoding/xml"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
+	"sync"
+	"time"
 )
 
 const (
@@ -21,31 +23,101 @@ const (
 
 	// DefaultOMReportCommandName is the default name of omreport subcommand passed to omcliproxy.
 	DefaultOMReportCommandName = "omreport"
+
+	// DefaultOMReportDir is the default path to directory that contains omreport.
+	DefaultOMReportDir = "/opt/dell/srvadmin/bin"
+
+	// DefaultOMReportBinaryName is the default name of the omreport binary.
+	DefaultOMReportBinaryName = "omreport"
+
+	// DefaultTimeout is the maximum amount of time a single omreport invocation may take
+	// when no timeout is configured.
+	DefaultTimeout = 2 * time.Minute
 )
 
 // An OMReporter gathers information from Dell's omreport utility.
 type OMReporter interface {
 	Report(...string) ([]byte, error)
+	ReportContext(context.Context, ...string) ([]byte, error)
+	ReportCommand(context.Context, Command) ([]byte, error)
+	Version() (*VersionOutput, error)
+	VersionContext(context.Context) (*VersionOutput, error)
 	Chassis() (*ChassisOutput, error)
+	ChassisContext(context.Context) (*ChassisOutput, error)
 	ChassisInfo() (*ChassisInfoOutput, error)
+	ChassisInfoContext(context.Context) (*ChassisInfoOutput, error)
 	ChassisBatteries() (*ChassisBatteriesOutput, error)
+	ChassisBatteriesContext(context.Context) (*ChassisBatteriesOutput, error)
 	ChassisFans() (*ChassisFansOutput, error)
+	ChassisFansContext(context.Context) (*ChassisFansOutput, error)
 	ChassisProcessors() (*ChassisProcessorsOutput, error)
+	ChassisProcessorsContext(context.Context) (*ChassisProcessorsOutput, error)
 	ChassisMemory() (*ChassisMemoryOutput, error)
+	ChassisMemoryContext(context.Context) (*ChassisMemoryOutput, error)
 	ChassisTemps() (*ChassisTempsOutput, error)
+	ChassisTempsContext(context.Context) (*ChassisTempsOutput, error)
+	ChassisVoltages() (*ChassisVoltagesOutput, error)
+	ChassisVoltagesContext(context.Context) (*ChassisVoltagesOutput, error)
+	ChassisIntrusion() (*ChassisIntrusionOutput, error)
+	ChassisIntrusionContext(context.Context) (*ChassisIntrusionOutput, error)
+	ChassisFrontPanel() (*ChassisFrontPanelOutput, error)
+	ChassisFrontPanelContext(context.Context) (*ChassisFrontPanelOutput, error)
+	ChassisNICs() (*ChassisNICsOutput, error)
+	ChassisNICsContext(context.Context) (*ChassisNICsOutput, error)
+	ChassisSlots() (*ChassisSlotsOutput, error)
+	ChassisSlotsContext(context.Context) (*ChassisSlotsOutput, error)
+	ChassisBIOS() (*ChassisBIOSOutput, error)
+	ChassisBIOSContext(context.Context) (*ChassisBIOSOutput, error)
+	ChassisBIOSSetup() (*ChassisBIOSSetupOutput, error)
+	ChassisBIOSSetupContext(context.Context) (*ChassisBIOSSetupOutput, error)
+	FirmwareInventory() (*FirmwareInventoryOutput, error)
+	FirmwareInventoryContext(context.Context) (*FirmwareInventoryOutput, error)
 	ChassisPowerMonitoring() (*ChassisPowerMonitoringOutput, error)
+	ChassisPowerMonitoringContext(context.Context) (*ChassisPowerMonitoringOutput, error)
 	ChassisPowerSupplies() (*ChassisPowerSuppliesOutput, error)
+	ChassisPowerSuppliesContext(context.Context) (*ChassisPowerSuppliesOutput, error)
 	StorageController() (*StorageControllerOutput, error)
+	StorageControllerContext(context.Context) (*StorageControllerOutput, error)
 	StorageEnclosure() (*StorageEnclosureOutput, error)
+	StorageEnclosureContext(context.Context) (*StorageEnclosureOutput, error)
 	StorageVDisk() (*StorageVDiskOutput, error)
+	StorageVDiskContext(context.Context) (*StorageVDiskOutput, error)
 	StoragePDisk(cid int) (*StoragePDiskOutput, error)
+	StoragePDiskContext(ctx context.Context, cid int) (*StoragePDiskOutput, error)
+	StreamReport(ctx context.Context, path string, fn func(decode func(v interface{}) error) error, args ...string) error
+	StreamStoragePDisk(ctx context.Context, cid int, fn func(PDisk) error) error
+	StreamESMLog(ctx context.Context, fn func(ESMLogEntry) error) error
+	Snapshot(context.Context) *SystemSnapshot
 	SuspiciousOMCLIProxyBinary() error
 }
 
 // OMReport implements OMReporter.
 type OMReport struct {
 	omCLIProxyPath       string
+	omReportPath         string
+	directOMReport       bool
 	enhancedSecurityMode bool
+	strictPermissions    bool
+	allowRawArgs         bool
+	timeout              time.Duration
+	exec                 Executor
+	recorder             *Recorder
+	maxConcurrency       int
+	maxRecordSize        int64
+	cache                *responseCache
+	retry                *RetryPolicy
+
+	versionMu       sync.Mutex
+	version         Version
+	versionKnown    bool
+	versionFailedAt time.Time
+
+	lockMu  sync.Mutex
+	lockErr error
+
+	initOnce sync.Once
+	sem      chan struct{}
+	calls    callGroup
 
 	sha256Checksum []byte
 }
@@ -57,51 +129,458 @@ type Config struct {
 	// Whether or not to enable enhanced security mode.
 	// Enabling this checks the sha256 of the omcliproxy binary
 	// and ensures that it has not been modified prior to executing it.
+	// On Linux, a sealed in-memory copy of the verified bytes is executed, so that the
+	// binary cannot be replaced or modified between its verification and its execution.
+	// Kernels without memfd_create additionally require StrictPermissions.
 	EnhancedSecurityMode bool
+
+	// Whether or not to run omreport directly instead of through omcliproxy,
+	// for hosts that only ship the omreport binary. The omreport binary is
+	// subject to the same checks as omcliproxy.
+	DirectOMReport bool
+
+	// Whether or not to require that only root can modify the binary. Enabling this checks
+	// that the binary is a regular file, that no component of its path is a symlink, and
+	// that the binary and every ancestor directory are owned by root and are neither
+	// group- nor world-writable. The checks are repeated along with the checksum in
+	// enhanced security mode. Ownership can only be checked on Unix platforms.
+	StrictPermissions bool
+
+	// Full path to the omreport binary, used when DirectOMReport is enabled.
+	// Defaults to omreport in DefaultOMReportDir.
+	OMReportPath string
+
+	// Known-good sha256 checksums of the binary, hex encoded and keyed by OMSA version, e.g. "8.5.0".
+	// If set, NewOMReporter fails with an *UntrustedBinaryError unless the checksum of the binary is
+	// trusted. When OMSAVersion is set, only the checksums listed for that version are trusted.
+	TrustedChecksums map[string][]string
+
+	// Path to a file listing known-good sha256 checksums of the binary in the format written by
+	// sha256sum, which are trusted in addition to TrustedChecksums regardless of the OMSA version.
+	TrustedChecksumsFile string
+
+	// Whether or not Report, ReportContext and StreamReport may run arbitrary arguments.
+	// By default, they only accept a known subcommand followed by parameters it accepts,
+	// like ParseCommand, and return a *CommandError otherwise.
+	AllowRawArgs bool
+
+	// Maximum amount of time a single omreport invocation may take before
+	// the omcliproxy process group is killed. Defaults to DefaultTimeout
+	// when zero. A negative value disables the timeout.
+	Timeout time.Duration
+
+	// Executor used to run omcliproxy. Defaults to a CommandExecutor configured with
+	// PassEnv, Env, Dir, Umask and Credential when nil.
+	// The omcliproxy binary is only verified when it is run by a CommandExecutor, since
+	// other executors may run it on a different host or filesystem. NewOMReporter returns
+	// ErrVerificationUnsupported if EnhancedSecurityMode, StrictPermissions, TrustedChecksums
+	// or TrustedChecksumsFile is set along with any other executor.
+	Executor Executor
+
+	// Names of environment variables of the current process passed through to omcliproxy,
+	// e.g. "TZ". omcliproxy otherwise runs with DefaultEnv only.
+	PassEnv []string
+
+	// Additional environment variables of omcliproxy as "key=value" strings, which take
+	// precedence over DefaultEnv and PassEnv.
+	Env []string
+
+	// Working directory of omcliproxy. Defaults to the working directory of the current process.
+	Dir string
+
+	// File mode creation mask of omcliproxy. Defaults to the umask of the current process when nil.
+	Umask *os.FileMode
+
+	// User and groups omcliproxy runs as, which allows read-only commands to run without
+	// root privileges. Defaults to the user and groups of the current process when nil.
+	// See CheckCapabilities for which commands work with a given credential.
+	Credential *Credential
+
+	// Recorder, if set, captures the raw output of every omreport invocation.
+	Recorder *Recorder
+
+	// Maximum number of omreport commands that may run at the same time.
+	// Defaults to DefaultMaxConcurrency when zero. Identical commands that are
+	// requested while one is already running share its result.
+	MaxConcurrency int
+
+	// Maximum number of bytes of output read while streaming a single record.
+	// Defaults to DefaultMaxRecordSize when zero. A negative value disables the limit.
+	MaxRecordSize int64
+
+	// Cache, if set, enables caching of omreport output.
+	Cache *CacheConfig
+
+	// Retry, if set, retries omreport commands that fail transiently.
+	Retry *RetryPolicy
+
+	// Version of OMSA installed, e.g. "8.5.0". Detected using 'omreport about' when empty.
+	// The version selects how typed methods decode the output of commands whose layout differs
+	// between releases, whether they return ErrUnsupported for commands the release does not
+	// have, and the checksums trusted from TrustedChecksums.
+	OMSAVersion string
 }
 
 // NewOMReporter returns a struct that implements OMReporter.
-// Returns an error if the provided path to omcliproxy is potentially
-// malicious and doesn't match known signatures.
+// Returns an error if the provided path to omcliproxy, or omreport when
+// DirectOMReport is enabled, is potentially malicious and doesn't match known signatures.
 func NewOMReporter(cfg *Config) (*OMReport, error) {
 	om := &OMReport{
 		omCLIProxyPath:       cfg.OMCLIProxyPath,
+		omReportPath:         cfg.OMReportPath,
+		directOMReport:       cfg.DirectOMReport,
 		enhancedSecurityMode: cfg.EnhancedSecurityMode,
+		strictPermissions:    cfg.StrictPermissions,
+		allowRawArgs:         cfg.AllowRawArgs,
+		timeout:              cfg.Timeout,
+		exec:                 cfg.Executor,
+		recorder:             cfg.Recorder,
+		maxConcurrency:       cfg.MaxConcurrency,
+		maxRecordSize:        cfg.MaxRecordSize,
+		retry:                cfg.Retry,
+	}
+	if om.exec == nil {
+		om.exec = &CommandExecutor{
+			Env:        environ(cfg.PassEnv, cfg.Env),
+			Dir:        cfg.Dir,
+			Umask:      cfg.Umask,
+			Credential: cfg.Credential,
+		}
+	}
+	if cfg.Cache != nil {
+		om.cache = newResponseCache(cfg.Cache)
+	}
+	if cfg.OMSAVersion != "" {
+		version, err := ParseVersion(cfg.OMSAVersion)
+		if err != nil {
+			return nil, err
+		}
+		om.version, om.versionKnown = version, true
+	}
+	om.init()
+	if !om.verifiesBinary() {
+		if cfg.EnhancedSecurityMode || cfg.StrictPermissions || cfg.TrustedChecksums != nil || cfg.TrustedChecksumsFile != "" {
+			return nil, ErrVerificationUnsupported
+		}
+		return om, nil
 	}
 	if err := om.allowedOMCLIProxyBinary(); err != nil {
 		return nil, err
 	}
-	checksum, err := fileSha256(cfg.OMCLIProxyPath)
+	checksum, err := fileSha256(om.binaryPath())
 	if err != nil {
 		return nil, err
 	}
+	trusted, err := trustedChecksums(cfg, om.version, om.versionKnown)
+	if err != nil {
+		return nil, err
+	}
+	if trusted != nil && !trusted[hex.EncodeToString(checksum)] {
+		return nil, &UntrustedBinaryError{Path: om.binaryPath(), Checksum: hex.EncodeToString(checksum)}
+	}
 	om.sha256Checksum = checksum
 	return om, nil
 }
 
 // Report runs the specified omreport command with provided arguments.
 func (om *OMReport) Report(args ...string) ([]byte, error) {
-	if om.omCLIProxyPath == "" {
-		om.omCLIProxyPath = filepath.Join(DefaultOMCLIProxyDir, DefaultOMCLIProxyBinaryName)
+	return om.ReportContext(context.Background(), args...)
+}
+
+// ReportContext runs the specified omreport command with provided arguments.
+// If ctx is done or the configured timeout elapses before the command completes,
+// the whole omcliproxy process group is killed. ErrTimeout is returned if the
+// command was killed because a deadline was exceeded. A *StatusError is returned
+// if omreport reports an error in-band.
+//
+// At most the configured number of commands run at the same time; ReportContext
+// blocks until a slot is available. Concurrent calls with identical arguments share
+// a single omcliproxy invocation. If caching is enabled, cached output is returned
+// instead of running omcliproxy whenever possible.
+//
+// Unless AllowRawArgs is enabled, a *CommandError is returned if args is not a known
+// subcommand followed by parameters it accepts. See ParseCommand.
+func (om *OMReport) ReportContext(ctx context.Context, args ...string) ([]byte, error) {
+	if !om.allowRawArgs {
+		if err := validateArgs(args); err != nil {
+			return nil, err
+		}
+	}
+	return om.reportRaw(ctx, args)
+}
+
+// reportRaw returns the output of the specified omreport command, or a *StatusError
+// if the output reports an in-band error.
+func (om *OMReport) reportRaw(ctx context.Context, args []string) ([]byte, error) {
+	resp, err := om.report(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := om.statusError(args, resp.data); err != nil {
+		return nil, err
+	}
+	return resp.data, nil
+}
+
+// statusError returns the *StatusError reported by the output of the specified omreport
+// command, if any. The output is removed from the cache so that the command runs again
+// rather than failing until the cached output expires.
+func (om *OMReport) statusError(args []string, data []byte) error {
+	err := checkStatus(args, data)
+	if err != nil && om.cache != nil {
+		om.cache.remove(args)
 	}
-	if om.enhancedSecurityMode {
-		if err := om.SuspiciousOMCLIProxyBinary(); err != nil {
+	return err
+}
+
+// InvalidateCache removes cached output of every command whose arguments start with args,
+// e.g. InvalidateCache("storage") invalidates every storage command. InvalidateCache
+// with no arguments empties the cache.
+func (om *OMReport) InvalidateCache(args ...string) {
+	if om.cache != nil {
+		om.cache.invalidate(args)
+	}
+}
+
+// response is the output of an omreport command.
+type response struct {
+	data        []byte
+	collectedAt time.Time
+	stale       bool
+}
+
+// report returns the output of the specified omreport command, from the cache if possible.
+func (om *OMReport) report(ctx context.Context, args []string) (*response, error) {
+	om.init()
+	// Output cached before om was locked may have been produced by the tampered binary.
+	if om.Locked() != nil {
+		return nil, ErrLocked
+	}
+	if om.cache != nil {
+		if resp, revalidate := om.cache.get(args, time.Now()); resp != nil {
+			if revalidate {
+				go om.revalidate(args)
+			}
+			return resp, nil
+		}
+	}
+	return om.fetch(ctx, args)
+}
+
+// fetch runs the specified omreport command, retrying it if it fails transiently and
+// sharing the invocation with concurrent callers requesting the same command, and
+// caches its output.
+func (om *OMReport) fetch(ctx context.Context, args []string) (*response, error) {
+	return om.calls.do(ctx, callKey(args), func(ctx context.Context) (*response, error) {
+		data, err := om.runWithRetry(ctx, args)
+		if err != nil {
 			return nil, err
 		}
+		resp := &response{data: data, collectedAt: time.Now()}
+		if om.cache != nil {
+			om.cache.put(args, data, resp.collectedAt)
+		}
+		return resp, nil
+	})
+}
+
+// revalidate refreshes the cached output of a stale command in the background.
+func (om *OMReport) revalidate(args []string) {
+	defer om.cache.revalidated(args)
+	_, _ = om.fetch(context.Background(), args)
+}
+
+// init initializes the state OMReport needs to run commands.
+func (om *OMReport) init() {
+	om.initOnce.Do(func() {
+		if om.omCLIProxyPath == "" {
+			om.omCLIProxyPath = filepath.Join(DefaultOMCLIProxyDir, DefaultOMCLIProxyBinaryName)
+		}
+		if om.omReportPath == "" {
+			om.omReportPath = filepath.Join(DefaultOMReportDir, DefaultOMReportBinaryName)
+		}
+		n := om.maxConcurrency
+		if n <= 0 {
+			n = DefaultMaxConcurrency
+		}
+		om.sem = make(chan struct{}, n)
+	})
+}
+
+// run runs the specified omreport command once a concurrency slot is available.
+func (om *OMReport) run(ctx context.Context, args []string) ([]byte, error) {
+	ctx, done, err := om.start(ctx)
+	if err != nil {
+		return nil, err
 	}
-	args = append([]string{DefaultOMReportCommandName}, args...)
-	args = append(args, "-fmt", "xml")
-	return exec.Command(om.omCLIProxyPath, args...).CombinedOutput()
+	defer done()
+
+	argv := om.argv(args)
+	start := time.Now()
+	data, err := om.execute(ctx, argv)
+	om.record(args, start, data, err)
+	if err != nil {
+		return nil, err
+	}
+	// In-band errors reported by XML documents are checked once the output is decoded.
+	if err := checkPlainStatus(args, data); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
-// About returns OMSA version information gathered from omreport.
-func (om *OMReport) About() (*AboutOutput, error) {
-	data, err := om.Report("about")
+// record passes an invocation of the specified omreport command that started at start
+// to the configured Recorder, if any.
+func (om *OMReport) record(args []string, start time.Time, data []byte, err error) {
+	if om.recorder == nil {
+		return
+	}
+	inv := Invocation{
+		Args:       args,
+		ExitStatus: exitStatus(err),
+		Time:       start,
+		Duration:   time.Since(start),
+		Output:     data,
+	}
+	if err != nil {
+		inv.Error = err.Error()
+	}
+	om.recorder.Record(inv)
+}
+
+// execute runs the binary with argv using the configured Executor. In enhanced security mode,
+// a binary run by a CommandExecutor is verified right before it is executed.
+func (om *OMReport) execute(ctx context.Context, argv []string) ([]byte, error) {
+	e, ok := om.executor().(*CommandExecutor)
+	if !ok || !om.enhancedSecurityMode {
+		return om.executor().Execute(ctx, om.binaryPath(), argv...)
+	}
+	cmd, f, err := om.verifiedCommand(argv)
 	if err != nil {
 		return nil, err
 	}
+	if f != nil {
+		defer f.Close()
+	}
+	return e.execute(ctx, cmd)
+}
+
+// start waits for a concurrency slot before an omreport command is run. It returns the
+// context the command must run with, bound to the configured timeout, and a function
+// that must be called to release the slot once the command exits.
+// Returns ErrLocked if om is locked.
+func (om *OMReport) start(ctx context.Context) (context.Context, func(), error) {
+	if err := acquire(ctx, om.sem); err != nil {
+		return nil, nil, err
+	}
+	if om.Locked() != nil {
+		release(om.sem)
+		return nil, nil, ErrLocked
+	}
+	timeout := om.timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {
+		cancel()
+		release(om.sem)
+	}, nil
+}
+
+// binaryPath returns the path to the binary that runs omreport commands.
+func (om *OMReport) binaryPath() string {
+	if om.directOMReport {
+		return om.omReportPath
+	}
+	return om.omCLIProxyPath
+}
+
+// binaryName returns the name the binary that runs omreport commands must have.
+func (om *OMReport) binaryName() string {
+	if om.directOMReport {
+		return DefaultOMReportBinaryName
+	}
+	return DefaultOMCLIProxyBinaryName
+}
+
+// argv returns the arguments passed to the binary to run the specified omreport command.
+// omcliproxy expects the omreport subcommand name first, while omreport is run with args as is.
+func (om *OMReport) argv(args []string) []string {
+	var argv []string
+	if !om.directOMReport {
+		argv = append(argv, DefaultOMReportCommandName)
+	}
+	argv = append(argv, args...)
+	return append(argv, "-fmt", "xml")
+}
+
+// executor returns the Executor used to run omcliproxy.
+func (om *OMReport) executor() Executor {
+	if om.exec == nil {
+		return &CommandExecutor{}
+	}
+	return om.exec
+}
+
+// environ returns DefaultEnv extended with the variables of the current process named
+// in passEnv and with env.
+func environ(passEnv, env []string) []string {
+	environ := append([]string(nil), DefaultEnv...)
+	for _, name := range passEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			environ = append(environ, name+"="+value)
+		}
+	}
+	return append(environ, env...)
+}
+
+// verifiesBinary reports whether the omcliproxy binary is executed locally and
+// is therefore subject to binary verification.
+func (om *OMReport) verifiesBinary() bool {
+	_, ok := om.executor().(*CommandExecutor)
+	return ok
+}
+
+// reportXML runs the specified omreport command and decodes its output into v, using the decoder
+// of the installed OMSA release. Returns ErrUnsupported if the release does not support the command.
+func (om *OMReport) reportXML(ctx context.Context, v interface{}, args ...string) error {
+	if err := om.checkVersion(ctx, args); err != nil {
+		return err
+	}
+	decode := om.decoder(ctx, args)
+	resp, err := om.report(ctx, args)
+	if err != nil {
+		return err
+	}
+	if err := decode(resp.data, v); err != nil {
+		return &ParseError{Args: args, Err: err}
+	}
+	out, ok := v.(interface{ envelope() *Envelope })
+	if !ok || out.envelope().SMStatus != SMStatusSuccess {
+		if err := om.statusError(args, resp.data); err != nil {
+			return err
+		}
+	}
+	if ok {
+		out.envelope().CollectedAt = resp.collectedAt
+		out.envelope().Stale = resp.stale
+	}
+	return nil
+}
+
+// About returns OMSA version information gathered from omreport.
+func (om *OMReport) About() (*AboutOutput, error) {
+	return om.AboutContext(context.Background())
+}
+
+// AboutContext is like About but honors the deadline and cancellation of ctx.
+func (om *OMReport) AboutContext(ctx context.Context) (*AboutOutput, error) {
 	out := AboutOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "about"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -109,12 +588,13 @@ func (om *OMReport) About() (*AboutOutput, error) {
 
 // Chassis returns server chassis information gathered from omreport.
 func (om *OMReport) Chassis() (*ChassisOutput, error) {
-	data, err := om.Report("chassis")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisContext(context.Background())
+}
+
+// ChassisContext is like Chassis but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisContext(ctx context.Context) (*ChassisOutput, error) {
 	out := ChassisOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -122,12 +602,13 @@ func (om *OMReport) Chassis() (*ChassisOutput, error) {
 
 // ChassisBatteries returns battery information gathered from omreport.
 func (om *OMReport) ChassisBatteries() (*ChassisBatteriesOutput, error) {
-	data, err := om.Report("chassis", "batteries")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisBatteriesContext(context.Background())
+}
+
+// ChassisBatteriesContext is like ChassisBatteries but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisBatteriesContext(ctx context.Context) (*ChassisBatteriesOutput, error) {
 	out := ChassisBatteriesOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "batteries"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -135,12 +616,13 @@ func (om *OMReport) ChassisBatteries() (*ChassisBatteriesOutput, error) {
 
 // ChassisFans returns fan information gathered from omreport.
 func (om *OMReport) ChassisFans() (*ChassisFansOutput, error) {
-	data, err := om.Report("chassis", "fans")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisFansContext(context.Background())
+}
+
+// ChassisFansContext is like ChassisFans but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisFansContext(ctx context.Context) (*ChassisFansOutput, error) {
 	out := ChassisFansOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "fans"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -148,25 +630,27 @@ func (om *OMReport) ChassisFans() (*ChassisFansOutput, error) {
 
 // ChassisInfo returns chassis information gathered from omreport.
 func (om *OMReport) ChassisInfo() (*ChassisInfoOutput, error) {
-	data, err := om.Report("chassis", "info")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisInfoContext(context.Background())
+}
+
+// ChassisInfoContext is like ChassisInfo but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisInfoContext(ctx context.Context) (*ChassisInfoOutput, error) {
 	out := ChassisInfoOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "info"); err != nil {
 		return nil, err
 	}
-	return &out, err
+	return &out, nil
 }
 
 // ChassisProcessors returns processor information gathered from omreport.
 func (om *OMReport) ChassisProcessors() (*ChassisProcessorsOutput, error) {
-	data, err := om.Report("chassis", "processors")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisProcessorsContext(context.Background())
+}
+
+// ChassisProcessorsContext is like ChassisProcessors but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisProcessorsContext(ctx context.Context) (*ChassisProcessorsOutput, error) {
 	out := ChassisProcessorsOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "processors"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -174,12 +658,13 @@ func (om *OMReport) ChassisProcessors() (*ChassisProcessorsOutput, error) {
 
 // ChassisMemory returns memory information gathered from omreport.
 func (om *OMReport) ChassisMemory() (*ChassisMemoryOutput, error) {
-	data, err := om.Report("chassis", "memory")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisMemoryContext(context.Background())
+}
+
+// ChassisMemoryContext is like ChassisMemory but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisMemoryContext(ctx context.Context) (*ChassisMemoryOutput, error) {
 	out := ChassisMemoryOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "memory"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -187,25 +672,125 @@ func (om *OMReport) ChassisMemory() (*ChassisMemoryOutput, error) {
 
 // ChassisTemps returns temperature information gathered from omreport.
 func (om *OMReport) ChassisTemps() (*ChassisTempsOutput, error) {
-	data, err := om.Report("chassis", "temps")
-	if err != nil {
+	return om.ChassisTempsContext(context.Background())
+}
+
+// ChassisTempsContext is like ChassisTemps but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisTempsContext(ctx context.Context) (*ChassisTempsOutput, error) {
+	out := ChassisTempsOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "temps"); err != nil {
 		return nil, err
 	}
-	out := ChassisTempsOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	return &out, nil
+}
+
+// ChassisVoltages returns voltage probe information gathered from omreport.
+func (om *OMReport) ChassisVoltages() (*ChassisVoltagesOutput, error) {
+	return om.ChassisVoltagesContext(context.Background())
+}
+
+// ChassisVoltagesContext is like ChassisVoltages but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisVoltagesContext(ctx context.Context) (*ChassisVoltagesOutput, error) {
+	out := ChassisVoltagesOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "volts"); err != nil {
 		return nil, err
 	}
 	return &out, nil
 }
 
-// ChassisPowerMonitoring returns power monitoring information gathered from omreport.
-func (om *OMReport) ChassisPowerMonitoring() (*ChassisPowerMonitoringOutput, error) {
-	data, err := om.Report("chassis", "pwrmonitoring")
-	if err != nil {
+// ChassisIntrusion returns chassis intrusion information gathered from omreport.
+func (om *OMReport) ChassisIntrusion() (*ChassisIntrusionOutput, error) {
+	return om.ChassisIntrusionContext(context.Background())
+}
+
+// ChassisIntrusionContext is like ChassisIntrusion but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisIntrusionContext(ctx context.Context) (*ChassisIntrusionOutput, error) {
+	out := ChassisIntrusionOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "intrusion"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChassisFrontPanel returns front panel settings gathered from omreport.
+func (om *OMReport) ChassisFrontPanel() (*ChassisFrontPanelOutput, error) {
+	return om.ChassisFrontPanelContext(context.Background())
+}
+
+// ChassisFrontPanelContext is like ChassisFrontPanel but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisFrontPanelContext(ctx context.Context) (*ChassisFrontPanelOutput, error) {
+	out := ChassisFrontPanelOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "frontpanel"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChassisNICs returns network interface information gathered from omreport.
+func (om *OMReport) ChassisNICs() (*ChassisNICsOutput, error) {
+	return om.ChassisNICsContext(context.Background())
+}
+
+// ChassisNICsContext is like ChassisNICs but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisNICsContext(ctx context.Context) (*ChassisNICsOutput, error) {
+	out := ChassisNICsOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "nics"); err != nil {
 		return nil, err
 	}
+	return &out, nil
+}
+
+// ChassisSlots returns PCI slot information gathered from omreport.
+func (om *OMReport) ChassisSlots() (*ChassisSlotsOutput, error) {
+	return om.ChassisSlotsContext(context.Background())
+}
+
+// ChassisSlotsContext is like ChassisSlots but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisSlotsContext(ctx context.Context) (*ChassisSlotsOutput, error) {
+	out := ChassisSlotsOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "slots"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChassisBIOS returns BIOS information gathered from omreport.
+func (om *OMReport) ChassisBIOS() (*ChassisBIOSOutput, error) {
+	return om.ChassisBIOSContext(context.Background())
+}
+
+// ChassisBIOSContext is like ChassisBIOS but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisBIOSContext(ctx context.Context) (*ChassisBIOSOutput, error) {
+	out := ChassisBIOSOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "bios"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChassisBIOSSetup returns BIOS settings gathered from omreport.
+func (om *OMReport) ChassisBIOSSetup() (*ChassisBIOSSetupOutput, error) {
+	return om.ChassisBIOSSetupContext(context.Background())
+}
+
+// ChassisBIOSSetupContext is like ChassisBIOSSetup but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisBIOSSetupContext(ctx context.Context) (*ChassisBIOSSetupOutput, error) {
+	out := ChassisBIOSSetupOutput{}
+	if err := om.reportXML(ctx, &out, "chassis", "biossetup"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChassisPowerMonitoring returns power monitoring information gathered from omreport.
+func (om *OMReport) ChassisPowerMonitoring() (*ChassisPowerMonitoringOutput, error) {
+	return om.ChassisPowerMonitoringContext(context.Background())
+}
+
+// ChassisPowerMonitoringContext is like ChassisPowerMonitoring but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisPowerMonitoringContext(ctx context.Context) (*ChassisPowerMonitoringOutput, error) {
 	out := ChassisPowerMonitoringOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "pwrmonitoring"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -213,12 +798,13 @@ func (om *OMReport) ChassisPowerMonitoring() (*ChassisPowerMonitoringOutput, err
 
 // ChassisPowerSupplies returns power supply information gathered from omreport.
 func (om *OMReport) ChassisPowerSupplies() (*ChassisPowerSuppliesOutput, error) {
-	data, err := om.Report("chassis", "pwrsupplies")
-	if err != nil {
-		return nil, err
-	}
+	return om.ChassisPowerSuppliesContext(context.Background())
+}
+
+// ChassisPowerSuppliesContext is like ChassisPowerSupplies but honors the deadline and cancellation of ctx.
+func (om *OMReport) ChassisPowerSuppliesContext(ctx context.Context) (*ChassisPowerSuppliesOutput, error) {
 	out := ChassisPowerSuppliesOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "chassis", "pwrsupplies"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -226,12 +812,13 @@ func (om *OMReport) ChassisPowerSupplies() (*ChassisPowerSuppliesOutput, error)
 
 // StorageController returns RAID controller information gathered from omreport.
 func (om *OMReport) StorageController() (*StorageControllerOutput, error) {
-	data, err := om.Report("storage", "controller")
-	if err != nil {
-		return nil, err
-	}
+	return om.StorageControllerContext(context.Background())
+}
+
+// StorageControllerContext is like StorageController but honors the deadline and cancellation of ctx.
+func (om *OMReport) StorageControllerContext(ctx context.Context) (*StorageControllerOutput, error) {
 	out := StorageControllerOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "storage", "controller"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -239,12 +826,13 @@ func (om *OMReport) StorageController() (*StorageControllerOutput, error) {
 
 // StorageEnclosure returns storage enclosure information gathered from omreport.
 func (om *OMReport) StorageEnclosure() (*StorageEnclosureOutput, error) {
-	data, err := om.Report("storage", "enclosure")
-	if err != nil {
-		return nil, err
-	}
+	return om.StorageEnclosureContext(context.Background())
+}
+
+// StorageEnclosureContext is like StorageEnclosure but honors the deadline and cancellation of ctx.
+func (om *OMReport) StorageEnclosureContext(ctx context.Context) (*StorageEnclosureOutput, error) {
 	out := StorageEnclosureOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "storage", "enclosure"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -252,12 +840,13 @@ func (om *OMReport) StorageEnclosure() (*StorageEnclosureOutput, error) {
 
 // StorageVDisk returns virtual disk information gathered from omreport.
 func (om *OMReport) StorageVDisk() (*StorageVDiskOutput, error) {
-	data, err := om.Report("storage", "vdisk")
-	if err != nil {
-		return nil, err
-	}
+	return om.StorageVDiskContext(context.Background())
+}
+
+// StorageVDiskContext is like StorageVDisk but honors the deadline and cancellation of ctx.
+func (om *OMReport) StorageVDiskContext(ctx context.Context) (*StorageVDiskOutput, error) {
 	out := StorageVDiskOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "storage", "vdisk"); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -266,12 +855,13 @@ func (om *OMReport) StorageVDisk() (*StorageVDiskOutput, error) {
 // StoragePDisk returns physical disk information associated with the provided storage
 // controller gathered from omreport.
 func (om *OMReport) StoragePDisk(cid int) (*StoragePDiskOutput, error) {
-	data, err := om.Report("storage", "pdisk", fmt.Sprintf("controller=%d", cid))
-	if err != nil {
-		return nil, err
-	}
+	return om.StoragePDiskContext(context.Background(), cid)
+}
+
+// StoragePDiskContext is like StoragePDisk but honors the deadline and cancellation of ctx.
+func (om *OMReport) StoragePDiskContext(ctx context.Context, cid int) (*StoragePDiskOutput, error) {
 	out := StoragePDiskOutput{}
-	if err := xml.Unmarshal(data, &out); err != nil {
+	if err := om.reportXML(ctx, &out, "storage", "pdisk", fmt.Sprintf("controller=%d", cid)); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -300,23 +890,30 @@ func (p *PDisk) DedicatedHotSpare() (bool, error) {
 
 // allowedOMCLIProxyBinary checks if the configured path to the omcliproxy executable is allowed to be executed.
 // An omcliproxy executable is allowed to be executed if all of the following conditions are true:
-//  - The binary name is 'omcliproxy'.
+//  - The binary name is 'omcliproxy', or 'omreport' when DirectOMReport is enabled.
 //  - The path is not a symlink.
+//  - With StrictPermissions enabled, only root can modify the binary (see checkPermissions).
 // Returns an error if the binary is not allowed to be executed or does not exist.
 func (om *OMReport) allowedOMCLIProxyBinary() error {
-	// The binary name must be 'omcliproxy'.
-	name := filepath.Base(om.omCLIProxyPath)
-	if name != DefaultOMCLIProxyBinaryName {
-		return fmt.Errorf("expected binary name to be %s", DefaultOMCLIProxyBinaryName)
+	// The binary name must be 'omcliproxy' or 'omreport'.
+	path := om.binaryPath()
+	expected := om.binaryName()
+	if name := filepath.Base(path); name != expected {
+		return fmt.Errorf("expected binary name to be %s", expected)
 	}
 
 	// The path must not be a symlink.
-	fi, err := os.Lstat(om.omCLIProxyPath)
+	fi, err := os.Lstat(path)
 	if err != nil {
 		return err
 	}
 	if fi.Mode()&os.ModeSymlink != 0 {
-		return fmt.Errorf("expected %s to not be a symlink", om.omCLIProxyPath)
+		return fmt.Errorf("expected %s to not be a symlink", path)
+	}
+
+	// Only root may be able to modify the binary.
+	if om.strictPermissions {
+		return checkPermissions(path)
 	}
 
 	return nil
@@ -327,14 +924,29 @@ func (om *OMReport) allowedOMCLIProxyBinary() error {
 // from the checksum computed when the omreport object was first instantiated using NewOMReporter. This implies
 // that something has changed the the executable contents underneath this process and that further execution should
 // proceed with caution.
+// With StrictPermissions enabled, the binary is also considered suspicious if anyone but root can modify it.
+// Binaries run by an Executor other than CommandExecutor are never considered suspicious.
 // Returns a non-nil error if the binary is considered suspicious or if the file checksum cannot be calculated.
 func (om *OMReport) SuspiciousOMCLIProxyBinary() error {
-	currentChecksum, err := fileSha256(om.omCLIProxyPath)
+	if !om.verifiesBinary() {
+		return nil
+	}
+	if om.strictPermissions {
+		if err := checkPermissions(om.binaryPath()); err != nil {
+			return err
+		}
+	}
+	currentChecksum, err := fileSha256(om.binaryPath())
 	if err != nil {
 		return err
 	}
-	if !bytes.Equal(currentChecksum, om.sha256Checksum) {
-		return fmt.Errorf("current binary checksum %s does not match the original checksum %s which is very suspicious", currentChecksum, om.sha256Checksum)
+	return om.checkChecksum(currentChecksum)
+}
+
+// checkChecksum returns an error if checksum differs from the checksum computed by NewOMReporter.
+func (om *OMReport) checkChecksum(checksum []byte) error {
+	if !bytes.Equal(checksum, om.sha256Checksum) {
+		return fmt.Errorf("current binary checksum %x does not match the original checksum %x which is very suspicious", checksum, om.sha256Checksum)
 	}
 	return nil
 }
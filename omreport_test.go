@@ -1,11 +1,13 @@
 package omreport
 
 import (
+	"context"
 	"encoding/xml"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,6 +35,29 @@ func TestNewOMReporter(t *testing.T) {
 		})
 		require.NoError(t, err, "testdata/omcliproxy should be allowed")
 	})
+	t.Run("not allowed omreport binary name", func(t *testing.T) {
+		_, err := NewOMReporter(&Config{
+			OMReportPath:   "testdata/omcliproxy",
+			DirectOMReport: true,
+		})
+		require.Error(t, err, "testdata/omcliproxy should not be allowed in direct omreport mode")
+	})
+	t.Run("not allowed omreport binary that is a symlink", func(t *testing.T) {
+		tmpDir, err := ioutil.TempDir(".", "")
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll(tmpDir)
+			require.NoError(t, err)
+		}()
+		err = os.Symlink("../testdata/omcliproxy", filepath.Join(tmpDir, "omreport"))
+		require.NoError(t, err)
+
+		_, err = NewOMReporter(&Config{
+			OMReportPath:   filepath.Join(tmpDir, "omreport"),
+			DirectOMReport: true,
+		})
+		require.Error(t, err, "omreport symlink should not be allowed")
+	})
 }
 
 func TestOMReport_SuspiciousOMCLIProxyBinary(t *testing.T) {
@@ -92,7 +117,8 @@ func TestOMReport_About_Unmarshal(t *testing.T) {
 	err = xml.Unmarshal(data, &out)
 	require.NoError(t, err)
 	assert.Equal(t, AboutOutput{
-		Version: "8.5.0",
+		Envelope: Envelope{UserRights: UserRightsUser},
+		Version:  "8.5.0",
 	}, out)
 }
 
@@ -164,6 +190,311 @@ func TestOMReport_ChassisTemps_Unmarshal(t *testing.T) {
 	}, out)
 }
 
+func TestOMReport_ChassisVoltages_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-volts.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisVoltagesOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisVoltagesOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
+		Status:   StatusNonCritical,
+		Probes: []VoltageProbe{
+			{
+				ID:                         0,
+				State:                      VoltageStateGood,
+				Status:                     StatusOK,
+				Location:                   "CPU1 VCORE PG",
+				MinNonRecoverableThreshold: NaN,
+				MinCriticalThreshold:       NaN,
+				MinNonCriticalThreshold:    NaN,
+				MaxNonCriticalThreshold:    NaN,
+				MaxCriticalThreshold:       NaN,
+				MaxNonRecoverableThreshold: NaN,
+			},
+			{
+				ID:                         1,
+				State:                      VoltageStateGood,
+				Status:                     StatusOK,
+				Location:                   "System Board 3.3V PG",
+				MinNonRecoverableThreshold: NaN,
+				MinCriticalThreshold:       NaN,
+				MinNonCriticalThreshold:    NaN,
+				MaxNonCriticalThreshold:    NaN,
+				MaxCriticalThreshold:       NaN,
+				MaxNonRecoverableThreshold: NaN,
+			},
+			{
+				ID:                         2,
+				Reading:                    232000,
+				Status:                     StatusOK,
+				Location:                   "PS1 Voltage 1",
+				MinNonRecoverableThreshold: NaN,
+				MinCriticalThreshold:       90000,
+				MinNonCriticalThreshold:    NaN,
+				MaxNonCriticalThreshold:    NaN,
+				MaxCriticalThreshold:       264000,
+				MaxNonRecoverableThreshold: NaN,
+			},
+			{
+				ID:                         3,
+				Reading:                    11520,
+				Status:                     StatusNonCritical,
+				Location:                   "System Board 12V",
+				MinNonRecoverableThreshold: 10200,
+				MinCriticalThreshold:       11000,
+				MinNonCriticalThreshold:    11700,
+				MaxNonCriticalThreshold:    12600,
+				MaxCriticalThreshold:       13200,
+				MaxNonRecoverableThreshold: 13800,
+			},
+		},
+	}, out)
+	assert.True(t, out.Probes[0].Discrete())
+	assert.False(t, out.Probes[3].Discrete())
+}
+
+func TestOMReport_ChassisIntrusion_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-intrusion.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisIntrusionOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisIntrusionOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
+		Status:   StatusCritical,
+		Probes: []IntrusionProbe{
+			{
+				ID:       0,
+				Location: "Intrusion",
+				Type:     IntrusionTypePoweredOn,
+				State:    IntrusionStateNotBreached,
+				Status:   StatusOK,
+			},
+			{
+				ID:       1,
+				Location: "Intrusion While Powered Off",
+				Type:     IntrusionTypePoweredOff,
+				State:    IntrusionStateBreachedPrior,
+				Status:   StatusCritical,
+			},
+		},
+	}, out)
+	assert.False(t, out.Probes[0].Breached())
+	assert.True(t, out.Probes[1].Breached())
+}
+
+func TestOMReport_ChassisFrontPanel_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-frontpanel.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisFrontPanelOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisFrontPanelOutput{
+		Envelope:           Envelope{UserRights: UserRightsUser},
+		PowerButtonEnabled: false,
+		NMIButtonEnabled:   true,
+		LCDAccess:          LCDAccessViewOnly,
+		LCDLines:           []string{"apps2.internal", "JZ31JH2"},
+		Status:             StatusOK,
+	}, out)
+}
+
+func TestOMReport_ChassisNICs_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-nics.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisNICsOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisNICsOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
+		Status:   StatusNonCritical,
+		NICs: []NIC{
+			{
+				ID:               0,
+				Name:             "eno1",
+				Vendor:           "Broadcom Inc. and subsidiaries",
+				Description:      "NetXtreme BCM5720 Gigabit Ethernet PCIe",
+				DriverVersion:    "4.18.0-348.el8.x86_64",
+				MACAddress:       "24:6E:96:1A:2B:3C",
+				IPv4Addresses:    []string{"10.20.30.41"},
+				IPv6Addresses:    []string{"fe80::266e:96ff:fe1a:2b3c"},
+				Speed:            1000,
+				ConnectionStatus: ConnectionStatusConnected,
+				Team:             "bond0",
+				Status:           StatusOK,
+			},
+			{
+				ID:               1,
+				Name:             "eno2",
+				Vendor:           "Broadcom Inc. and subsidiaries",
+				Description:      "NetXtreme BCM5720 Gigabit Ethernet PCIe",
+				DriverVersion:    "4.18.0-348.el8.x86_64",
+				MACAddress:       "24:6E:96:1A:2B:3D",
+				ConnectionStatus: ConnectionStatusDisconnected,
+				Team:             "bond0",
+				Status:           StatusNonCritical,
+			},
+			{
+				ID:               2,
+				Name:             "p2p1",
+				Vendor:           "Intel Corporation",
+				Description:      "Ethernet Controller X710 for 10GbE SFP+",
+				DriverVersion:    "2.3.2-k",
+				MACAddress:       "3C:FD:FE:A1:B2:C4",
+				IPv4Addresses:    []string{"10.40.0.12", "10.40.0.13"},
+				Speed:            10000,
+				ConnectionStatus: ConnectionStatusConnected,
+				Status:           StatusOK,
+			},
+		},
+		Teams: []NICTeam{
+			{
+				ID:               0,
+				Name:             "bond0",
+				Description:      "Linux Bonding Driver",
+				MACAddress:       "24:6E:96:1A:2B:3C",
+				ConnectionStatus: ConnectionStatusConnected,
+				Members:          []string{"eno1", "eno2"},
+				Status:           StatusNonCritical,
+			},
+		},
+	}, out)
+}
+
+func TestOMReport_ChassisSlots_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-slots.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisSlotsOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisSlotsOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
+		Slots: []Slot{
+			{
+				ID:           1,
+				Name:         "PCI1",
+				Type:         "PCI Express Gen 3 X8",
+				DataBusWidth: 8,
+				Usage:        SlotUsageAvailable,
+			},
+			{
+				ID:           3,
+				Name:         "PCI3",
+				Adapter:      "Intel(R) Ethernet Controller X710 for 10GbE SFP+",
+				Type:         "PCI Express Gen 3 X16",
+				DataBusWidth: 16,
+				Usage:        SlotUsageInUse,
+				PCIBus:       59,
+			},
+			{
+				ID:           5,
+				Name:         "PCI5",
+				Adapter:      "PERC H810 Adapter",
+				Type:         "PCI Express Gen 2 X8",
+				DataBusWidth: 8,
+				Usage:        SlotUsageInUse,
+				PCIBus:       3,
+			},
+		},
+	}, out)
+}
+
+func TestSlot_Controller(t *testing.T) {
+	controllers := []Controller{
+		{ID: 1, Name: "PERC H710P Mini", PCIBus: 2},
+		{ID: 0, Name: "PERC H810 Adapter", PCIBus: 3},
+		{ID: 2, Name: "PERC H330 Mini"},
+	}
+
+	slot := Slot{ID: 5, Usage: SlotUsageInUse, PCIBus: 3}
+	require.NotNil(t, slot.Controller(controllers))
+	assert.Equal(t, "PERC H810 Adapter", slot.Controller(controllers).Name)
+
+	slot = Slot{ID: 3, Usage: SlotUsageInUse, PCIBus: 59}
+	assert.Nil(t, slot.Controller(controllers))
+
+	// Empty slots report no PCI address and never match a controller on bus 0.
+	slot = Slot{ID: 1, Usage: SlotUsageAvailable}
+	assert.Nil(t, slot.Controller(controllers))
+}
+
+func TestOMReport_ChassisBIOS_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-bios.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisBIOSOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisBIOSOutput{
+		Envelope:     Envelope{UserRights: UserRightsUser},
+		Manufacturer: "Dell Inc.",
+		Version:      "2.13.0",
+		ReleaseDate:  "05/14/2021",
+	}, out)
+}
+
+func TestOMReport_ChassisBIOSSetup_Unmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/omreport-chassis-biossetup.xml")
+	require.NoError(t, err, "Failed to read testdata.")
+
+	out := ChassisBIOSSetupOutput{}
+	err = xml.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChassisBIOSSetupOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
+		Settings: []BIOSSetting{
+			{
+				Name:          "ProcVirtualization",
+				DisplayName:   "Virtualization Technology",
+				Type:          BIOSSettingTypeEnumeration,
+				Value:         "Enabled",
+				AllowedValues: []string{"Enabled", "Disabled"},
+			},
+			{
+				Name:          "BootMode",
+				DisplayName:   "Boot Mode",
+				Type:          BIOSSettingTypeEnumeration,
+				Value:         "Uefi",
+				AllowedValues: []string{"Bios", "Uefi"},
+			},
+			{
+				Name:        "AssetTag",
+				DisplayName: "Asset Tag",
+				Type:        BIOSSettingTypeString,
+				Value:       "RACK12-U07",
+			},
+			{
+				Name:        "SysMemSize",
+				DisplayName: "System Memory Size",
+				Type:        BIOSSettingTypeInteger,
+				Value:       "262144",
+				ReadOnly:    true,
+			},
+		},
+	}, out)
+
+	require.NotNil(t, out.Setting("SysMemSize"))
+	size, err := out.Setting("SysMemSize").Int()
+	require.NoError(t, err)
+	assert.Equal(t, 262144, size)
+	_, err = out.Setting("BootMode").Int()
+	assert.Error(t, err)
+	assert.Nil(t, out.Setting("NoSuchSetting"))
+}
+
 func TestOMReport_StorageVDisk_Unmarshal(t *testing.T) {
 	data, err := ioutil.ReadFile("testdata/omreport-storage-vdisk.xml")
 	require.NoError(t, err, "Failed to read testdata.")
@@ -172,6 +503,7 @@ func TestOMReport_StorageVDisk_Unmarshal(t *testing.T) {
 	err = xml.Unmarshal(data, &out)
 	require.NoError(t, err)
 	assert.Equal(t, StorageVDiskOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
 		VDisks: []VDisk{
 			{
 				ID:          0,
@@ -205,6 +537,7 @@ func TestOMReport_StoragePDisk_Unmarshal(t *testing.T) {
 	err = xml.Unmarshal(data, &out)
 	require.NoError(t, err)
 	assert.Equal(t, StoragePDiskOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
 		PDisks: []PDisk{
 			{
 				AttributesMask: "00000000000000000010010000010000",
@@ -261,18 +594,23 @@ func TestOMReport_StorageController_Unmarshal(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, StorageControllerOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
 		Controllers: []Controller{
 			{
-				ID:     1,
-				Name:   "PERC H710P Mini",
-				Status: StatusOK,
-				State:  StateReady,
+				ID:              1,
+				Name:            "PERC H710P Mini",
+				Status:          StatusOK,
+				State:           StateReady,
+				FirmwareVersion: "21.3.4-0001",
+				PCIBus:          2,
 			},
 			{
-				ID:     0,
-				Name:   "PERC H810 Adapter",
-				Status: StatusOK,
-				State:  StateReady,
+				ID:              0,
+				Name:            "PERC H810 Adapter",
+				Status:          StatusOK,
+				State:           StateReady,
+				FirmwareVersion: "21.3.4-0001",
+				PCIBus:          3,
 			},
 		},
 	}, out)
@@ -287,12 +625,14 @@ func TestOMReport_StorageEnclosure_Umarshal(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, StorageEnclosureOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
 		Enclosures: []Enclosure{
 			{
-				ID:           3,
-				ControllerID: 0,
-				Status:       StatusOK,
-				State:        StateReady,
+				ID:              3,
+				ControllerID:    0,
+				Status:          StatusOK,
+				State:           StateReady,
+				FirmwareVersion: "3.31",
 			},
 		},
 	}, out)
@@ -307,6 +647,7 @@ func TestOMReport_ChassisPowerMonitoring_Unmarshal(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, ChassisPowerMonitoringOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
 		Probes: []PowerProbe{
 			{
 				ID:                0,
@@ -338,6 +679,7 @@ func TestOMReport_ChassisMemory_Unmarshal(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, ChassisMemoryOutput{
+		Envelope:                    Envelope{UserRights: UserRightsUser},
 		TotalPhysicalMemorySize:     263858184,
 		AvailablePhysicalMemorySize: 35390420,
 		Dimms: []Dimm{
@@ -373,6 +715,7 @@ func TestOMReport_ChassisBatteries_Unmarshal(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, ChassisBatteriesOutput{
+		Envelope: Envelope{UserRights: UserRightsUser},
 		Probes: []BatteryProbe{
 			{
 				ID:       0,
@@ -520,3 +863,69 @@ func TestOMReport_ChassisPowerSupplies_Unmarshal(t *testing.T) {
 		},
 	}, out)
 }
+
+func TestOMReport_ReportContext(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binaryPath := filepath.Join(tmpDir, "omcliproxy")
+
+	t.Run("command output", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho \"$@\"\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath})
+		require.NoError(t, err)
+
+		out, err := om.ReportContext(context.Background(), "chassis", "temps")
+		require.NoError(t, err)
+		assert.Equal(t, "omreport chassis temps -fmt xml\n", string(out))
+	})
+	t.Run("direct omreport command output", func(t *testing.T) {
+		omreportPath := filepath.Join(tmpDir, "omreport")
+		err := ioutil.WriteFile(omreportPath, []byte("#!/bin/sh\necho \"$@\"\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{
+			OMReportPath:         omreportPath,
+			DirectOMReport:       true,
+			EnhancedSecurityMode: true,
+		})
+		require.NoError(t, err)
+
+		out, err := om.ReportContext(context.Background(), "chassis", "temps")
+		require.NoError(t, err)
+		assert.Equal(t, "chassis temps -fmt xml\n", string(out))
+
+		err = ioutil.WriteFile(omreportPath, []byte("#!/bin/sh\necho modified\n"), 0755)
+		require.NoError(t, err)
+		_, err = om.ReportContext(context.Background(), "chassis", "temps")
+		require.Error(t, err, "modified omreport binary should be considered suspicious")
+	})
+	t.Run("timeout kills process group", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\nsleep 30 &\nwait\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{
+			OMCLIProxyPath: binaryPath,
+			Timeout:        100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = om.ReportContext(context.Background(), "chassis")
+		assert.Equal(t, ErrTimeout, err)
+		assert.True(t, time.Since(start) < 10*time.Second, "orphaned children should not keep the command alive")
+	})
+	t.Run("cancellation", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\nsleep 30\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		_, err = om.ChassisContext(ctx)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
@@ -0,0 +1,85 @@
+package omreport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkPermissions returns an error unless nobody but root can modify the binary at path
+// or redirect it to another file, i.e. unless all of the following conditions are true:
+//   - No component of the absolute path is a symlink.
+//   - The binary is a regular file.
+//   - The binary and every ancestor directory are owned by root.
+//   - The binary and every ancestor directory are neither group- nor world-writable.
+func checkPermissions(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	// Every component of the path must not be a symlink. The components are checked
+	// from the root down so that the reported symlink is the one that is followed first.
+	var infos []os.FileInfo
+	current := string(filepath.Separator)
+	for _, name := range strings.Split(abs, string(filepath.Separator)) {
+		if name == "" {
+			continue
+		}
+		current = filepath.Join(current, name)
+		fi, err := os.Lstat(current)
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("expected %s to not be a symlink", current)
+		}
+		infos = append(infos, fi)
+	}
+	root, err := os.Lstat(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+	infos = append([]os.FileInfo{root}, infos...)
+
+	// The binary must be a regular file.
+	binary := infos[len(infos)-1]
+	if !binary.Mode().IsRegular() {
+		return fmt.Errorf("expected %s to be a regular file", abs)
+	}
+
+	// The binary and its ancestors must only be writable by root, checked from the binary up.
+	paths := []string{abs}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		paths = append(paths, dir)
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for i, p := range paths {
+		if err := checkWritableByRootOnly(p, infos[len(infos)-1-i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWritableByRootOnly returns an error unless the file at path is owned by root and
+// is neither group- nor world-writable.
+func checkWritableByRootOnly(path string, fi os.FileInfo) error {
+	if fi.Mode().Perm()&0020 != 0 {
+		return fmt.Errorf("expected %s to not be group-writable", path)
+	}
+	if fi.Mode().Perm()&0002 != 0 {
+		return fmt.Errorf("expected %s to not be world-writable", path)
+	}
+	uid, ok := fileOwner(fi)
+	if !ok {
+		return fmt.Errorf("unable to determine owner of %s", path)
+	}
+	if uid != 0 {
+		return fmt.Errorf("expected %s to be owned by root, but it is owned by uid %d", path, uid)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package omreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binDir := filepath.Join(tmpDir, "sbin")
+	require.NoError(t, os.Mkdir(binDir, 0755))
+	binaryPath := filepath.Join(binDir, "omcliproxy")
+	require.NoError(t, ioutil.WriteFile(binaryPath, []byte("foo"), 0755))
+
+	t.Run("symlink ancestor", func(t *testing.T) {
+		link := filepath.Join(tmpDir, "link")
+		require.NoError(t, os.Symlink("sbin", link))
+		err := checkPermissions(filepath.Join(link, "omcliproxy"))
+		require.Error(t, err)
+		assert.True(t, strings.HasSuffix(err.Error(), "link to not be a symlink"), err.Error())
+	})
+	t.Run("not a regular file", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "omreport")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		err := checkPermissions(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "to be a regular file")
+	})
+	t.Run("group-writable binary", func(t *testing.T) {
+		require.NoError(t, os.Chmod(binaryPath, 0775))
+		defer os.Chmod(binaryPath, 0755)
+		err := checkPermissions(binaryPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "omcliproxy to not be group-writable")
+	})
+	t.Run("world-writable binary", func(t *testing.T) {
+		require.NoError(t, os.Chmod(binaryPath, 0757))
+		defer os.Chmod(binaryPath, 0755)
+		err := checkPermissions(binaryPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "omcliproxy to not be world-writable")
+	})
+	t.Run("world-writable ancestor", func(t *testing.T) {
+		if os.Getuid() != 0 {
+			t.Skip("the binary is rejected for not being owned by root before its ancestors are checked")
+		}
+		require.NoError(t, os.Chmod(binDir, 0757))
+		defer os.Chmod(binDir, 0755)
+		err := checkPermissions(binaryPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sbin to not be world-writable")
+	})
+	t.Run("binary not owned by root", func(t *testing.T) {
+		if os.Getuid() == 0 {
+			t.Skip("files created by root are owned by root")
+		}
+		err := checkPermissions(binaryPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "omcliproxy to be owned by root")
+	})
+}
+
+func TestNewOMReporter_StrictPermissions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binaryPath := filepath.Join(tmpDir, "omcliproxy")
+	require.NoError(t, ioutil.WriteFile(binaryPath, []byte("foo"), 0755))
+	require.NoError(t, os.Chmod(binaryPath, 0757))
+
+	_, err = NewOMReporter(&Config{
+		OMCLIProxyPath:    binaryPath,
+		StrictPermissions: true,
+	})
+	require.Error(t, err, "world-writable omcliproxy should not be allowed")
+
+	_, err = NewOMReporter(&Config{OMCLIProxyPath: binaryPath})
+	require.NoError(t, err, "permissions should only be checked when enabled")
+}
@@ -0,0 +1,206 @@
+package omreport
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	scrubbedHostname   = "scrubbed-host"
+	scrubbedServiceTag = "SCRUBBED"
+)
+
+// scrubbedElements are the XML elements whose contents identify a particular host,
+// along with the placeholder their contents are replaced with.
+var scrubbedElements = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{scrubbedElementRegexp("SystemName"), scrubbedHostname},
+	{scrubbedElementRegexp("HostName"), scrubbedHostname},
+	{scrubbedElementRegexp("ServiceTag"), scrubbedServiceTag},
+	{scrubbedElementRegexp("NodeId"), scrubbedServiceTag},
+	{scrubbedElementRegexp("ExpressServiceCode"), scrubbedServiceTag},
+}
+
+func scrubbedElementRegexp(elem string) *regexp.Regexp {
+	return regexp.MustCompile(`<` + elem + `(?:\s[^>]*)?>([^<]+)</` + elem + `>`)
+}
+
+// Invocation describes a single recorded omreport invocation.
+type Invocation struct {
+	// Args of the omreport command, e.g. ["storage", "pdisk", "controller=0"].
+	Args []string `json:"args"`
+
+	// Exit status of the omcliproxy process, or -1 if it could not be determined.
+	ExitStatus int `json:"exit_status"`
+
+	// Time at which the invocation started.
+	Time time.Time `json:"time"`
+
+	// Amount of time the invocation took.
+	Duration time.Duration `json:"duration_ns"`
+
+	// Error returned by the invocation, if any.
+	Error string `json:"error,omitempty"`
+
+	// Output of the invocation.
+	Output []byte `json:"-"`
+}
+
+// A Recorder captures the raw output of omreport invocations so that it can be attached to
+// bug reports. Output is written using the same layout that FixtureReporter reads, along with
+// a JSON file describing each invocation, so that recordings replay directly as test fixtures.
+// Only the JSON file is written for failed invocations, which FixtureReporter replays as an *ExecError.
+// The output of streamed commands is recorded too, which requires buffering all of it in memory.
+// Hostnames and service tags are scrubbed from the output before it is written.
+type Recorder struct {
+	mu  sync.Mutex
+	dir string
+	tw  *tar.Writer
+	err error
+
+	replacements map[string]string
+	placeholders map[string]int
+}
+
+// NewDirRecorder returns a Recorder that writes recordings into dir, creating it if necessary.
+func NewDirRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return newRecorder(&Recorder{dir: dir}), nil
+}
+
+// NewTarRecorder returns a Recorder that writes recordings into a tar archive written to w.
+// Close must be called to flush the archive.
+func NewTarRecorder(w io.Writer) *Recorder {
+	return newRecorder(&Recorder{tw: tar.NewWriter(w)})
+}
+
+func newRecorder(r *Recorder) *Recorder {
+	r.replacements = map[string]string{}
+	r.placeholders = map[string]int{}
+	if hostname, err := os.Hostname(); err == nil {
+		r.addReplacement(hostname, scrubbedHostname)
+	}
+	return r
+}
+
+// Record writes inv and its scrubbed output. Errors are sticky and reported by Close,
+// so that a failing recording never interferes with the omreport invocation itself.
+func (r *Recorder) Record(inv Invocation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	inv.Output = r.scrub(inv.Output)
+	inv.Error = string(r.scrub([]byte(inv.Error)))
+	meta, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		r.err = err
+		return
+	}
+	name := fixtureName(inv.Args)
+	if inv.Error == "" {
+		if err := r.write(name, inv.Output, inv.Time); err != nil {
+			r.err = err
+			return
+		}
+	}
+	r.err = r.write(metadataName(name), meta, inv.Time)
+}
+
+// Close flushes any buffered recordings and returns the first error encountered while recording.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tw != nil {
+		if err := r.tw.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	return r.err
+}
+
+// write writes a single recorded file.
+func (r *Recorder) write(name string, data []byte, modTime time.Time) error {
+	if r.tw == nil {
+		return ioutil.WriteFile(filepath.Join(r.dir, name), data, 0644)
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := r.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := r.tw.Write(data)
+	return err
+}
+
+// scrub replaces hostnames and service tags in data with placeholders. Values found in
+// identifying elements are remembered, so they are also scrubbed wherever else they appear
+// in this or any later recording.
+func (r *Recorder) scrub(data []byte) []byte {
+	for _, elem := range scrubbedElements {
+		for _, m := range elem.re.FindAllSubmatch(data, -1) {
+			r.addReplacement(string(m[1]), elem.placeholder)
+		}
+	}
+	values := make([]string, 0, len(r.replacements))
+	for value := range r.replacements {
+		values = append(values, value)
+	}
+	// Replace longer values first so that a short hostname never clobbers part of its FQDN.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	s := string(data)
+	for _, value := range values {
+		s = strings.Replace(s, value, r.replacements[value], -1)
+	}
+	return []byte(s)
+}
+
+// addReplacement registers value to be scrubbed. Each distinct value gets its own numbered
+// placeholder so that relationships between scrubbed values are preserved.
+func (r *Recorder) addReplacement(value, placeholder string) {
+	value = strings.TrimSpace(value)
+	if len(value) < 3 || value == "Unknown" {
+		return
+	}
+	if _, ok := r.replacements[value]; ok {
+		return
+	}
+	r.placeholders[placeholder]++
+	r.replacements[value] = fmt.Sprintf("%s%d", placeholder, r.placeholders[placeholder])
+	// Short hostnames commonly appear alongside fully qualified ones.
+	if short := strings.SplitN(value, ".", 2)[0]; placeholder == scrubbedHostname && short != value {
+		r.addReplacement(short, placeholder)
+	}
+}
+
+// exitStatus returns the exit status of a process from the error returned by an Executor,
+// or -1 if it could not be determined.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if execErr, ok := err.(*ExecError); ok {
+		return execErr.ExitCode
+	}
+	return -1
+}
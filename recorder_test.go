@@ -0,0 +1,133 @@
+package omreport
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Dir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+
+	rec, err := NewDirRecorder(tmpDir)
+	require.NoError(t, err)
+	om, err := NewOMReporter(&Config{
+		Executor:     &fixtureExecutor{dir: "testdata"},
+		Recorder:     rec,
+		AllowRawArgs: true,
+	})
+	require.NoError(t, err)
+	_, err = om.ChassisInfo()
+	require.NoError(t, err)
+	_, err = om.Report("system", "alertlog")
+	require.Error(t, err)
+	require.NoError(t, rec.Close())
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpDir, "omreport-chassis-info.xml"))
+	require.NoError(t, err)
+	for _, s := range []string{"apps2.internal", "JZ31JH2", "JZ43HH2", "43480291286"} {
+		assert.NotContains(t, string(data), s, "identifying values should be scrubbed")
+	}
+
+	meta, err := ioutil.ReadFile(filepath.Join(tmpDir, "omreport-system-alertlog.json"))
+	require.NoError(t, err)
+	inv := Invocation{}
+	require.NoError(t, json.Unmarshal(meta, &inv))
+	assert.Equal(t, []string{"system", "alertlog"}, inv.Args)
+	assert.Equal(t, -1, inv.ExitStatus)
+	assert.Contains(t, inv.Error, "not recorded")
+
+	t.Run("recordings replay as fixtures", func(t *testing.T) {
+		fixtures, err := NewFixtureReporter(tmpDir)
+		require.NoError(t, err)
+		out, err := fixtures.ChassisInfo()
+		require.NoError(t, err)
+		require.Len(t, out.ChassisList, 1)
+		assert.True(t, strings.HasPrefix(out.ChassisList[0].Hostname, "scrubbed-host"))
+		assert.True(t, strings.HasPrefix(out.ChassisList[0].ServiceTag, "SCRUBBED"))
+		assert.Equal(t, "PowerEdge FC430", out.ChassisList[0].Model)
+
+		_, err = os.Stat(filepath.Join(tmpDir, "omreport-system-alertlog.xml"))
+		assert.True(t, os.IsNotExist(err), "output of failed invocations should not be recorded as a fixture")
+		_, err = fixtures.Report("system", "alertlog")
+		require.Error(t, err, "failed invocations should replay as failures")
+		execErr, ok := err.(*ExecError)
+		require.True(t, ok, "expected an ExecError, got %T", err)
+		assert.Equal(t, -1, execErr.ExitCode)
+		assert.Contains(t, execErr.Error(), "not recorded")
+	})
+}
+
+func TestRecorder_Tar(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewTarRecorder(&buf)
+	om, err := NewOMReporter(&Config{
+		Executor:    &fixtureExecutor{dir: "testdata"},
+		Recorder:    rec,
+		OMSAVersion: "8.5.0",
+	})
+	require.NoError(t, err)
+	_, err = om.ChassisTemps()
+	require.NoError(t, err)
+	require.NoError(t, rec.Close())
+
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []string{"omreport-chassis-temps.xml", "omreport-chassis-temps.json"}, names)
+}
+
+func TestRecorder_Stream(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+
+	rec, err := NewDirRecorder(tmpDir)
+	require.NoError(t, err)
+	om, err := NewOMReporter(&Config{
+		Executor:    &fixtureExecutor{dir: "testdata"},
+		Recorder:    rec,
+		OMSAVersion: "8.5.0",
+	})
+	require.NoError(t, err)
+	err = om.StreamStoragePDisk(context.Background(), 0, func(PDisk) error { return nil })
+	require.NoError(t, err)
+	require.NoError(t, rec.Close())
+
+	fixtures, err := NewOMReporter(&Config{Executor: &fixtureExecutor{dir: tmpDir}, OMSAVersion: "8.5.0"})
+	require.NoError(t, err)
+	out, err := fixtures.StoragePDisk(0)
+	require.NoError(t, err)
+	assert.Len(t, out.PDisks, 3)
+}
+
+func TestRecorder_exitStatus(t *testing.T) {
+	assert.Equal(t, 0, exitStatus(nil))
+	assert.Equal(t, 3, exitStatus(&ExecError{ExitCode: 3}))
+	assert.Equal(t, -1, exitStatus(ErrTimeout))
+}
@@ -0,0 +1,134 @@
+package omreport
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetryInitialBackoff is the default delay before the first retry.
+	DefaultRetryInitialBackoff = time.Second
+
+	// DefaultRetryMaxBackoff is the default upper bound of the delay between retries.
+	DefaultRetryMaxBackoff = 30 * time.Second
+
+	// DefaultRetryMultiplier is the default factor the delay grows by after every retry.
+	DefaultRetryMultiplier = 2
+)
+
+// transientExitCodes are exit codes that indicate a temporary failure (EX_UNAVAILABLE and EX_TEMPFAIL).
+var transientExitCodes = map[int]bool{
+	69: true,
+	75: true,
+}
+
+// transientSignatures are fragments of omreport output that indicate that the OMSA services
+// are not (yet) available, e.g. right after boot or after 'srvadmin-services.sh restart'.
+var transientSignatures = []string{
+	"service is not running",
+	"services are not running",
+	"service not running",
+	"services not running",
+	"not started",
+	"unable to connect",
+	"connection refused",
+	"data manager",
+	"dataeng",
+	"temporarily unavailable",
+	"try again",
+}
+
+// RetryPolicy configures retries of omreport commands that fail transiently.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first one. Commands are not retried when less than 2.
+	MaxAttempts int
+
+	// Delay before the first retry. Defaults to DefaultRetryInitialBackoff when zero.
+	InitialBackoff time.Duration
+
+	// Upper bound of the delay between retries. Defaults to DefaultRetryMaxBackoff when zero.
+	MaxBackoff time.Duration
+
+	// Factor the delay grows by after every retry. Defaults to DefaultRetryMultiplier when less than 1.
+	Multiplier float64
+
+	// Fraction of the delay, between 0 and 1, by which each delay is randomly increased or decreased.
+	Jitter float64
+
+	// Reports whether a failed command should be retried. Defaults to IsTransient when nil.
+	Retryable func(error) bool
+}
+
+// IsTransient reports whether err indicates a failure that is likely to go away on its own,
+// such as the OMSA services not running yet. Timeouts are not considered transient, since
+// retrying a wedged service only multiplies the time spent waiting for it.
+func IsTransient(err error) bool {
+	switch e := err.(type) {
+	case *ExecError:
+		return transientExitCodes[e.ExitCode] || hasTransientSignature(e.Stderr) || hasTransientSignature(e.Output)
+	case *StatusError:
+		return hasTransientSignature(e.Message)
+	default:
+		return false
+	}
+}
+
+// hasTransientSignature returns true if s contains any of transientSignatures.
+func hasTransientSignature(s string) bool {
+	s = strings.ToLower(s)
+	for _, signature := range transientSignatures {
+		if strings.Contains(s, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	initial, max, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = DefaultRetryInitialBackoff
+	}
+	if max <= 0 {
+		max = DefaultRetryMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = DefaultRetryMultiplier
+	}
+	delay := math.Min(float64(initial)*math.Pow(multiplier, float64(retry-1)), float64(max))
+	if p.Jitter > 0 {
+		delay *= 1 + math.Min(p.Jitter, 1)*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// retryable reports whether the failed command should be retried.
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransient(err)
+}
+
+// runWithRetry runs the specified omreport command, retrying it according to the configured
+// retry policy for as long as it fails transiently.
+func (om *OMReport) runWithRetry(ctx context.Context, args []string) ([]byte, error) {
+	p := om.retry
+	for attempt := 1; ; attempt++ {
+		data, err := om.run(ctx, args)
+		if err == nil || p == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+			return data, err
+		}
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
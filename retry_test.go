@@ -0,0 +1,108 @@
+package omreport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&ExecError{ExitCode: 1, Stderr: "dsm_sa_datamgrd service is not running"}))
+	assert.True(t, IsTransient(&ExecError{ExitCode: 75}))
+	assert.True(t, IsTransient(&StatusError{Message: "Unable to connect to the Server Administrator Data Manager"}))
+	assert.False(t, IsTransient(&ExecError{ExitCode: 1, Stderr: "omreport: command not found"}))
+	assert.False(t, IsTransient(&StatusError{Message: "Invalid controller value.", Kind: ErrInvalidController}))
+	assert.False(t, IsTransient(ErrTimeout))
+	assert.False(t, IsTransient(&ParseError{}))
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, p.backoff(3))
+	assert.Equal(t, time.Second, p.backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := p.backoff(2)
+		assert.True(t, delay >= 100*time.Millisecond && delay <= 300*time.Millisecond, "delay %s out of jitter bounds", delay)
+	}
+}
+
+func TestOMReport_Retry(t *testing.T) {
+	// failingExecutor fails the first failures invocations with err before serving fixtures.
+	failingExecutor := func(failures int, err error, calls *int) Executor {
+		fixtures := &fixtureExecutor{dir: "testdata"}
+		return ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			*calls++
+			if *calls <= failures {
+				return nil, err
+			}
+			return fixtures.Execute(ctx, name, args...)
+		})
+	}
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	serviceDown := &ExecError{ExitCode: 1, Stderr: "Error! The service is not running."}
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		calls := 0
+		om, err := NewOMReporter(&Config{Executor: failingExecutor(2, serviceDown, &calls), Retry: policy, OMSAVersion: "8.5.0"})
+		require.NoError(t, err)
+		out, err := om.ChassisTemps()
+		require.NoError(t, err)
+		assert.Len(t, out.Probes, 1)
+		assert.Equal(t, 3, calls)
+	})
+	t.Run("attempts are bounded", func(t *testing.T) {
+		calls := 0
+		om, err := NewOMReporter(&Config{Executor: failingExecutor(5, serviceDown, &calls), Retry: policy, OMSAVersion: "8.5.0"})
+		require.NoError(t, err)
+		_, err = om.Report("chassis", "temps")
+		assert.Equal(t, serviceDown, err)
+		assert.Equal(t, 3, calls)
+	})
+	t.Run("permanent failures are not retried", func(t *testing.T) {
+		calls := 0
+		permanent := &ExecError{ExitCode: 127, Stderr: "omcliproxy: not found"}
+		om, err := NewOMReporter(&Config{Executor: failingExecutor(1, permanent, &calls), Retry: policy, OMSAVersion: "8.5.0"})
+		require.NoError(t, err)
+		_, err = om.ChassisTemps()
+		assert.Equal(t, permanent, err)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("custom classification", func(t *testing.T) {
+		calls := 0
+		om, err := NewOMReporter(&Config{
+			Executor:    failingExecutor(1, errors.New("flaky"), &calls),
+			OMSAVersion: "8.5.0",
+			Retry: &RetryPolicy{
+				MaxAttempts:    2,
+				InitialBackoff: time.Millisecond,
+				Retryable:      func(error) bool { return true },
+			},
+		})
+		require.NoError(t, err)
+		_, err = om.ChassisTemps()
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("cancellation stops retrying", func(t *testing.T) {
+		calls := 0
+		om, err := NewOMReporter(&Config{
+			Executor:    failingExecutor(5, serviceDown, &calls),
+			Retry:       &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour},
+			OMSAVersion: "8.5.0",
+		})
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = om.ChassisTempsContext(ctx)
+		assert.Equal(t, serviceDown, err)
+		assert.Equal(t, 1, calls)
+	})
+}
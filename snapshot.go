@@ -0,0 +1,209 @@
+package omreport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SystemSnapshot models the output of every omreport command supported by this package,
+// collected in a single pass. Outputs of sections that could not be collected are nil,
+// and the error encountered is recorded in Sections.
+type SystemSnapshot struct {
+	// StartedAt is the time at which collection started.
+	StartedAt time.Time
+
+	// Duration is the amount of time the whole collection took.
+	Duration time.Duration
+
+	About                  *AboutOutput
+	Chassis                *ChassisOutput
+	ChassisInfo            *ChassisInfoOutput
+	ChassisBatteries       *ChassisBatteriesOutput
+	ChassisFans            *ChassisFansOutput
+	ChassisProcessors      *ChassisProcessorsOutput
+	ChassisMemory          *ChassisMemoryOutput
+	ChassisTemps           *ChassisTempsOutput
+	ChassisVoltages        *ChassisVoltagesOutput
+	ChassisIntrusion       *ChassisIntrusionOutput
+	ChassisFrontPanel      *ChassisFrontPanelOutput
+	ChassisNICs            *ChassisNICsOutput
+	ChassisSlots           *ChassisSlotsOutput
+	ChassisBIOS            *ChassisBIOSOutput
+	ChassisBIOSSetup       *ChassisBIOSSetupOutput
+	ChassisPowerMonitoring *ChassisPowerMonitoringOutput
+	ChassisPowerSupplies   *ChassisPowerSuppliesOutput
+	StorageController      *StorageControllerOutput
+	StorageEnclosure       *StorageEnclosureOutput
+	StorageVDisk           *StorageVDiskOutput
+
+	// StoragePDisks maps the ID of every controller in StorageController to its physical disks.
+	StoragePDisks map[int]*StoragePDiskOutput
+
+	// Sections describes the collection of each section, keyed by omreport command,
+	// e.g. "chassis temps" or "storage pdisk controller=0".
+	Sections map[string]*SnapshotSection
+}
+
+// SnapshotSection describes the collection of a single section of a SystemSnapshot.
+type SnapshotSection struct {
+	// StartedAt is the time at which collection of the section started.
+	StartedAt time.Time
+
+	// Duration is the amount of time collection of the section took.
+	Duration time.Duration
+
+	// Err is the error encountered while collecting the section, if any.
+	Err error
+}
+
+// Errors returns the errors encountered while collecting the snapshot, keyed by omreport command.
+func (s *SystemSnapshot) Errors() map[string]error {
+	errs := map[string]error{}
+	for name, section := range s.Sections {
+		if section.Err != nil {
+			errs[name] = section.Err
+		}
+	}
+	return errs
+}
+
+// Snapshot collects the output of every omreport command supported by this package in parallel,
+// including the physical disks of every storage controller. Commands are still subject to the
+// configured concurrency limit. Errors are recorded per section rather than aborting the snapshot.
+func (om *OMReport) Snapshot(ctx context.Context) *SystemSnapshot {
+	s := &SystemSnapshot{
+		StartedAt:     time.Now(),
+		StoragePDisks: map[int]*StoragePDiskOutput{},
+		Sections:      map[string]*SnapshotSection{},
+	}
+	c := &snapshotCollector{ctx: ctx, snapshot: s}
+
+	c.collect("about", func(ctx context.Context) (err error) {
+		s.About, err = om.AboutContext(ctx)
+		return err
+	})
+	c.collect("chassis", func(ctx context.Context) (err error) {
+		s.Chassis, err = om.ChassisContext(ctx)
+		return err
+	})
+	c.collect("chassis info", func(ctx context.Context) (err error) {
+		s.ChassisInfo, err = om.ChassisInfoContext(ctx)
+		return err
+	})
+	c.collect("chassis batteries", func(ctx context.Context) (err error) {
+		s.ChassisBatteries, err = om.ChassisBatteriesContext(ctx)
+		return err
+	})
+	c.collect("chassis fans", func(ctx context.Context) (err error) {
+		s.ChassisFans, err = om.ChassisFansContext(ctx)
+		return err
+	})
+	c.collect("chassis processors", func(ctx context.Context) (err error) {
+		s.ChassisProcessors, err = om.ChassisProcessorsContext(ctx)
+		return err
+	})
+	c.collect("chassis memory", func(ctx context.Context) (err error) {
+		s.ChassisMemory, err = om.ChassisMemoryContext(ctx)
+		return err
+	})
+	c.collect("chassis temps", func(ctx context.Context) (err error) {
+		s.ChassisTemps, err = om.ChassisTempsContext(ctx)
+		return err
+	})
+	c.collect("chassis volts", func(ctx context.Context) (err error) {
+		s.ChassisVoltages, err = om.ChassisVoltagesContext(ctx)
+		return err
+	})
+	c.collect("chassis intrusion", func(ctx context.Context) (err error) {
+		s.ChassisIntrusion, err = om.ChassisIntrusionContext(ctx)
+		return err
+	})
+	c.collect("chassis frontpanel", func(ctx context.Context) (err error) {
+		s.ChassisFrontPanel, err = om.ChassisFrontPanelContext(ctx)
+		return err
+	})
+	c.collect("chassis nics", func(ctx context.Context) (err error) {
+		s.ChassisNICs, err = om.ChassisNICsContext(ctx)
+		return err
+	})
+	c.collect("chassis slots", func(ctx context.Context) (err error) {
+		s.ChassisSlots, err = om.ChassisSlotsContext(ctx)
+		return err
+	})
+	c.collect("chassis bios", func(ctx context.Context) (err error) {
+		s.ChassisBIOS, err = om.ChassisBIOSContext(ctx)
+		return err
+	})
+	c.collect("chassis biossetup", func(ctx context.Context) (err error) {
+		s.ChassisBIOSSetup, err = om.ChassisBIOSSetupContext(ctx)
+		return err
+	})
+	c.collect("chassis pwrmonitoring", func(ctx context.Context) (err error) {
+		s.ChassisPowerMonitoring, err = om.ChassisPowerMonitoringContext(ctx)
+		return err
+	})
+	c.collect("chassis pwrsupplies", func(ctx context.Context) (err error) {
+		s.ChassisPowerSupplies, err = om.ChassisPowerSuppliesContext(ctx)
+		return err
+	})
+	c.collect("storage enclosure", func(ctx context.Context) (err error) {
+		s.StorageEnclosure, err = om.StorageEnclosureContext(ctx)
+		return err
+	})
+	c.collect("storage vdisk", func(ctx context.Context) (err error) {
+		s.StorageVDisk, err = om.StorageVDiskContext(ctx)
+		return err
+	})
+	c.collect("storage controller", func(ctx context.Context) error {
+		controllers, err := om.StorageControllerContext(ctx)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		s.StorageController = controllers
+		c.mu.Unlock()
+		for _, controller := range controllers.Controllers {
+			cid := controller.ID
+			c.collect(fmt.Sprintf("storage pdisk controller=%d", cid), func(ctx context.Context) error {
+				pdisks, err := om.StoragePDiskContext(ctx, cid)
+				if err != nil {
+					return err
+				}
+				c.mu.Lock()
+				s.StoragePDisks[cid] = pdisks
+				c.mu.Unlock()
+				return nil
+			})
+		}
+		return nil
+	})
+
+	c.wg.Wait()
+	s.Duration = time.Since(s.StartedAt)
+	return s
+}
+
+// snapshotCollector collects the sections of a SystemSnapshot concurrently.
+type snapshotCollector struct {
+	ctx      context.Context
+	snapshot *SystemSnapshot
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// collect runs fn in its own goroutine and records its timing and error as the named section.
+// Each fn assigns a distinct field of the snapshot, so only shared maps need c.mu.
+func (c *snapshotCollector) collect(name string, fn func(ctx context.Context) error) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		section := &SnapshotSection{StartedAt: time.Now()}
+		section.Err = fn(c.ctx)
+		section.Duration = time.Since(section.StartedAt)
+		c.mu.Lock()
+		c.snapshot.Sections[name] = section
+		c.mu.Unlock()
+	}()
+}
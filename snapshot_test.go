@@ -0,0 +1,71 @@
+package omreport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_Snapshot(t *testing.T) {
+	om, err := NewFixtureReporter("testdata")
+	require.NoError(t, err)
+
+	s := om.Snapshot(context.Background())
+	assert.Empty(t, s.Errors())
+	assert.True(t, s.Duration > 0)
+
+	require.NotNil(t, s.About)
+	assert.Equal(t, "8.5.0", s.About.Version)
+	require.NotNil(t, s.ChassisTemps)
+	assert.Len(t, s.ChassisTemps.Probes, 1)
+	require.NotNil(t, s.ChassisVoltages)
+	assert.Len(t, s.ChassisVoltages.Probes, 4)
+	require.NotNil(t, s.ChassisIntrusion)
+	assert.Len(t, s.ChassisIntrusion.Probes, 2)
+	require.NotNil(t, s.ChassisFrontPanel)
+	assert.Equal(t, LCDAccessViewOnly, s.ChassisFrontPanel.LCDAccess)
+	require.NotNil(t, s.ChassisNICs)
+	assert.Len(t, s.ChassisNICs.NICs, 3)
+	require.NotNil(t, s.ChassisSlots)
+	require.NotNil(t, s.StorageController)
+	assert.Equal(t, "PERC H810 Adapter", s.ChassisSlots.Slots[2].Controller(s.StorageController.Controllers).Name)
+	require.NotNil(t, s.ChassisBIOS)
+	assert.Equal(t, "2.13.0", s.ChassisBIOS.Version)
+	require.NotNil(t, s.ChassisBIOSSetup)
+	assert.Len(t, s.ChassisBIOSSetup.Settings, 4)
+	require.NotNil(t, s.StorageController)
+	assert.Len(t, s.StorageController.Controllers, 2)
+
+	require.Len(t, s.StoragePDisks, 2, "physical disks should be collected for every controller")
+	assert.Len(t, s.StoragePDisks[0].PDisks, 3)
+	assert.Len(t, s.StoragePDisks[1].PDisks, 3)
+
+	for _, name := range []string{"about", "chassis", "chassis temps", "storage controller", "storage pdisk controller=0", "storage pdisk controller=1"} {
+		section, ok := s.Sections[name]
+		require.True(t, ok, "missing section %s", name)
+		assert.False(t, section.StartedAt.IsZero())
+		assert.NoError(t, section.Err)
+	}
+}
+
+func TestOMReport_Snapshot_SectionErrors(t *testing.T) {
+	om, err := NewOMReporter(&Config{
+		Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			args = omreportArgs(args)
+			if len(args) == 2 && args[1] == "fans" {
+				return []byte("Error! Invalid command: fans"), nil
+			}
+			return (&fixtureExecutor{dir: "testdata"}).Execute(ctx, name, args...)
+		}),
+	})
+	require.NoError(t, err)
+
+	s := om.Snapshot(context.Background())
+	errs := s.Errors()
+	require.Len(t, errs, 1)
+	assert.IsType(t, &StatusError{}, errs["chassis fans"])
+	assert.Nil(t, s.ChassisFans)
+	assert.NotNil(t, s.ChassisTemps)
+}
@@ -0,0 +1,282 @@
+package omreport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRecordSize is the default maximum number of bytes of output read while
+// streaming a single record.
+const DefaultMaxRecordSize = 1 << 20
+
+// ErrRecordTooLarge is returned when a streamed record exceeds the configured maximum record size.
+var ErrRecordTooLarge = errors.New("omreport record too large")
+
+// StreamReport runs the specified omreport command and calls fn for every record in its output,
+// in order, while the output is being read. Records are the elements at path, which is relative
+// to the root element and uses the same 'parent>child' syntax as struct tags, e.g.
+// "ArrayDisks>DCStorageObject". fn decodes the record by calling decode, which behaves like
+// xml.Unmarshal. Records fn does not decode are skipped.
+//
+// Only a single record is held in memory at a time: at most the configured maximum record size
+// is read while decoding a record or between two records, otherwise ErrRecordTooLarge is returned.
+// The output is only streamed if the Executor implements StreamExecutor, otherwise it is buffered.
+//
+// If fn returns an error, the command is stopped and the error is returned. Streamed commands are
+// subject to the configured concurrency limit, timeout and binary verification, but are never
+// cached, retried or shared with concurrent callers. args are validated like in ReportContext.
+// Like typed methods, StreamReport returns ErrUnsupported if the installed OMSA release does not
+// support the command, but records are always decoded according to the struct tags of v.
+//
+// If a Recorder is configured, the whole output is also buffered so that it can be recorded once
+// the command is done, regardless of the maximum record size.
+func (om *OMReport) StreamReport(ctx context.Context, path string, fn func(decode func(v interface{}) error) error, args ...string) error {
+	if !om.allowRawArgs {
+		if err := validateArgs(args); err != nil {
+			return err
+		}
+	}
+	om.init()
+	if err := om.checkVersion(ctx, args); err != nil {
+		return err
+	}
+
+	ctx, done, err := om.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	start := time.Now()
+	var recorded bytes.Buffer
+	output, err := om.stream(ctx, args)
+	if err == nil {
+		if om.recorder != nil {
+			output = &teeReadCloser{Reader: io.TeeReader(output, &recorded), Closer: output}
+		}
+		err = om.decodeStream(output, args, strings.Split(path, ">"), fn)
+		closeErr := output.Close()
+		switch {
+		case ctx.Err() != nil:
+			err = contextError(ctx)
+		case closeErr != nil:
+			err = closeErr
+		}
+	}
+
+	// Like in run, in-band errors are recorded as the output that reports them.
+	recordErr := err
+	if _, ok := err.(*StatusError); ok {
+		recordErr = nil
+	}
+	om.record(args, start, recorded.Bytes(), recordErr)
+	return err
+}
+
+// teeReadCloser reads from Reader and closes Closer.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// stream starts the specified omreport command and returns its output.
+func (om *OMReport) stream(ctx context.Context, args []string) (io.ReadCloser, error) {
+	if e, ok := om.executor().(*CommandExecutor); ok && om.enhancedSecurityMode {
+		cmd, f, err := om.verifiedCommand(om.argv(args))
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			// The started process holds its own descriptor of the verified binary.
+			defer f.Close()
+		}
+		return e.executeStream(ctx, cmd)
+	}
+	if e, ok := om.executor().(StreamExecutor); ok {
+		return e.ExecuteStream(ctx, om.binaryPath(), om.argv(args)...)
+	}
+	data, err := om.executor().Execute(ctx, om.binaryPath(), om.argv(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// decodeStream calls fn for every element at path in output and returns a *StatusError if the
+// output reports an in-band error, like checkStatus.
+func (om *OMReport) decodeStream(output io.Reader, args []string, path []string, fn func(decode func(v interface{}) error) error) error {
+	limit := om.maxRecordSize
+	if limit == 0 {
+		limit = DefaultMaxRecordSize
+	}
+	r := &limitedReader{r: bufio.NewReader(output), limit: limit}
+	d := xml.NewDecoder(r)
+
+	var (
+		stack    []string
+		text     bytes.Buffer
+		rooted   bool
+		doc      errorDocument
+		parseErr = func(err error) error {
+			if err == ErrRecordTooLarge {
+				return err
+			}
+			return &ParseError{Args: args, Err: err}
+		}
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return parseErr(err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if !rooted {
+				rooted = true
+				continue
+			}
+			stack = append(stack, tok.Name.Local)
+			switch {
+			case len(stack) == 1 && tok.Name.Local == "SMStatus":
+				var status SMStatus
+				if err := d.DecodeElement(&status, &tok); err != nil {
+					return parseErr(err)
+				}
+				doc.SMStatus = append(doc.SMStatus, status)
+			case len(stack) == 1 && tok.Name.Local == "UserMsg":
+				var msg string
+				if err := d.DecodeElement(&msg, &tok); err != nil {
+					return parseErr(err)
+				}
+				doc.Messages = append(doc.Messages, msg)
+			case equalPath(stack, path):
+				r.n = 0
+				decoded := false
+				decode := func(v interface{}) error {
+					if decoded {
+						return fmt.Errorf("record already decoded")
+					}
+					decoded = true
+					if err := d.DecodeElement(v, &tok); err != nil {
+						return parseErr(err)
+					}
+					return nil
+				}
+				if err := fn(decode); err != nil {
+					return err
+				}
+				if !decoded {
+					if err := d.Skip(); err != nil {
+						return parseErr(err)
+					}
+				}
+				r.n = 0
+			default:
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if !rooted {
+				text.Write(tok)
+			}
+		}
+	}
+
+	if trimmed := bytes.TrimSpace(text.Bytes()); !rooted && bytes.HasPrefix(trimmed, []byte("Error!")) {
+		msg := string(bytes.TrimSpace(bytes.TrimPrefix(trimmed, []byte("Error!"))))
+		return newStatusError(args, SMStatusUnsuccessful, msg)
+	}
+	for _, status := range doc.SMStatus {
+		if status != SMStatusSuccess {
+			return newStatusError(args, status, strings.TrimSpace(strings.Join(doc.Messages, " ")))
+		}
+	}
+	return nil
+}
+
+// equalPath returns true if the element names in stack equal path.
+func equalPath(stack, path []string) bool {
+	if len(stack) != len(path) {
+		return false
+	}
+	for i := range stack {
+		if stack[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// limitedReader returns ErrRecordTooLarge once more than limit bytes were read since n was last reset.
+// It implements io.ByteReader so that xml.Decoder does not read ahead of the record being decoded.
+type limitedReader struct {
+	r     *bufio.Reader
+	n     int64
+	limit int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.limit > 0 {
+		if r.n >= r.limit {
+			return 0, ErrRecordTooLarge
+		}
+		if room := r.limit - r.n; int64(len(p)) > room {
+			p = p[:room]
+		}
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *limitedReader) ReadByte() (byte, error) {
+	if r.limit > 0 && r.n >= r.limit {
+		return 0, ErrRecordTooLarge
+	}
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.n++
+	}
+	return b, err
+}
+
+// StreamStoragePDisk calls fn for every physical disk attached to the specified controller while
+// the output of omreport is being read, which avoids holding every disk of large enclosures in memory.
+// See StreamReport for how the output is streamed.
+func (om *OMReport) StreamStoragePDisk(ctx context.Context, cid int, fn func(PDisk) error) error {
+	return om.StreamReport(ctx, "ArrayDisks>DCStorageObject", func(decode func(v interface{}) error) error {
+		pdisk := PDisk{}
+		if err := decode(&pdisk); err != nil {
+			return err
+		}
+		return fn(pdisk)
+	}, "storage", "pdisk", fmt.Sprintf("controller=%d", cid))
+}
+
+// StreamESMLog calls fn for every entry of the ESM log, in the order omreport lists them, while
+// the output of omreport is being read, which avoids holding the whole log in memory. See
+// StreamReport for how the output is streamed.
+func (om *OMReport) StreamESMLog(ctx context.Context, fn func(ESMLogEntry) error) error {
+	return om.StreamReport(ctx, "LogList>LogEntry", func(decode func(v interface{}) error) error {
+		entry := ESMLogEntry{}
+		if err := decode(&entry); err != nil {
+			return err
+		}
+		return fn(entry)
+	}, "system", "esmlog")
+}
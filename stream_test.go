@@ -0,0 +1,155 @@
+package omreport
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_StreamStoragePDisk(t *testing.T) {
+	om, err := NewFixtureReporter("testdata")
+	require.NoError(t, err)
+
+	t.Run("streamed records match decoded output", func(t *testing.T) {
+		expected, err := om.StoragePDisk(0)
+		require.NoError(t, err)
+
+		var pdisks []PDisk
+		err = om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			pdisks = append(pdisks, pdisk)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expected.PDisks, pdisks)
+	})
+	t.Run("callback error stops streaming", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			calls++
+			return stop
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("in-band error", func(t *testing.T) {
+		err := om.StreamStoragePDisk(context.Background(), 9, func(pdisk PDisk) error {
+			return nil
+		})
+		require.Error(t, err)
+		statusErr, ok := err.(*StatusError)
+		require.True(t, ok, "expected a StatusError, got %T", err)
+		assert.Equal(t, ErrInvalidController, statusErr.Kind)
+	})
+	t.Run("record too large", func(t *testing.T) {
+		om, err := NewOMReporter(&Config{
+			Executor:      &fixtureExecutor{dir: "testdata"},
+			MaxRecordSize: 256,
+			OMSAVersion:   "8.5.0",
+		})
+		require.NoError(t, err)
+		err = om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			return nil
+		})
+		assert.Equal(t, ErrRecordTooLarge, err)
+	})
+}
+
+func TestOMReport_StreamESMLog(t *testing.T) {
+	om, err := NewOMReporter(&Config{
+		Executor:    &fixtureExecutor{dir: "testdata"},
+		OMSAVersion: "8.5.0",
+	})
+	require.NoError(t, err)
+
+	var entries []ESMLogEntry
+	err = om.StreamESMLog(context.Background(), func(entry ESMLogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ESMLogEntry{
+		{ID: 0, Severity: StatusOK, Time: "Mon Mar 01 09:12:44 2021", Description: "Log cleared."},
+		{ID: 1, Severity: StatusNonCritical, Time: "Tue Mar 09 17:40:03 2021", Description: "Correctable memory error rate exceeded for DIMM_A2."},
+		{ID: 2, Severity: StatusCritical, Time: "Wed Mar 10 02:05:51 2021", Description: "The chassis is open while the power is off."},
+	}, entries)
+}
+
+func TestCommandExecutor_ExecuteStream(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binaryPath := filepath.Join(tmpDir, "omcliproxy")
+	fixturePath, err := filepath.Abs("testdata/omreport-storage-pdisk.xml")
+	require.NoError(t, err)
+
+	t.Run("records", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\ncat "+fixturePath+"\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath, OMSAVersion: "8.5.0"})
+		require.NoError(t, err)
+
+		var ids []string
+		err = om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			ids = append(ids, pdisk.SerialNo)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, ids, 3)
+	})
+	t.Run("exit status", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\ncat "+fixturePath+"\necho failed >&2\nexit 3\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath, OMSAVersion: "8.5.0"})
+		require.NoError(t, err)
+
+		err = om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			return nil
+		})
+		require.Error(t, err)
+		execErr, ok := err.(*ExecError)
+		require.True(t, ok, "expected an ExecError, got %T", err)
+		assert.Equal(t, 3, execErr.ExitCode)
+		assert.Equal(t, "failed\n", execErr.Stderr)
+	})
+	t.Run("callback error stops command", func(t *testing.T) {
+		script := "#!/bin/sh\ncat " + fixturePath + " | head -n -2\nwhile :; do echo '<ArrayDisks><DCStorageObject/></ArrayDisks>'; done\n"
+		err := ioutil.WriteFile(binaryPath, []byte(script), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{OMCLIProxyPath: binaryPath, OMSAVersion: "8.5.0"})
+		require.NoError(t, err)
+
+		stop := errors.New("stop")
+		start := time.Now()
+		err = om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			return stop
+		})
+		assert.Equal(t, stop, err)
+		assert.True(t, time.Since(start) < 10*time.Second, "command should be stopped once streaming stops")
+	})
+	t.Run("timeout", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho '<OMA>'\nsleep 30\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{
+			OMCLIProxyPath: binaryPath,
+			OMSAVersion:    "8.5.0",
+			Timeout:        100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		err = om.StreamStoragePDisk(context.Background(), 0, func(pdisk PDisk) error {
+			return nil
+		})
+		assert.Equal(t, ErrTimeout, err)
+	})
+}
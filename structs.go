@@ -2,6 +2,8 @@ package omreport
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 )
 
 // BusProtocol models the bus protocol used by a hardware component.
@@ -16,6 +18,36 @@ type State int
 // Layout models the layout of a RAID (e.g. RAID-0, RAID-1, RAID-10, etc.)
 type Layout int
 
+// SMStatus models the systems management status code reported with every omreport response.
+type SMStatus int
+
+// UserRights models the OMSA privilege level of the user that ran omreport.
+type UserRights int
+
+// VoltageState models the state reported by a discrete voltage probe.
+type VoltageState int
+
+// IntrusionType models the conditions under which an intrusion sensor detects chassis breaches.
+type IntrusionType int
+
+// IntrusionState models the state reported by an intrusion sensor.
+type IntrusionState int
+
+// LCDAccess models the access to the front panel LCD granted to local users.
+type LCDAccess int
+
+// ConnectionStatus models the link state of a network interface.
+type ConnectionStatus int
+
+// SlotUsage models whether a PCI slot is populated, as defined by SMBIOS.
+type SlotUsage int
+
+// BIOSSettingType models the type of the value of a BIOS setting.
+type BIOSSettingType int
+
+// FirmwareType models the kind of component running a firmware.
+type FirmwareType int
+
 const (
 	AttrLogicalConnector = 1 << 6
 	AttrGlobalHS         = 1 << 7
@@ -52,17 +84,81 @@ const (
 	BusProtocolSAS  BusProtocol = 8
 	BusProtocolPCIe BusProtocol = 9
 
+	SMStatusUnsuccessful     SMStatus = -1
+	SMStatusSuccess          SMStatus = 0
+	SMStatusNotImplemented   SMStatus = 1
+	SMStatusInvalidParameter SMStatus = 2
+	SMStatusNoSuchDevice     SMStatus = 7
+	SMStatusAccessDenied     SMStatus = 14
+
+	UserRightsUser      UserRights = 1
+	UserRightsPowerUser UserRights = 3
+	UserRightsAdmin     UserRights = 7
+
+	VoltageStateGood VoltageState = 1
+	VoltageStateBad  VoltageState = 2
+
+	IntrusionTypePoweredOn  IntrusionType = 1
+	IntrusionTypePoweredOff IntrusionType = 2
+
+	IntrusionStateNotBreached   IntrusionState = 1
+	IntrusionStateBreached      IntrusionState = 2
+	IntrusionStateBreachedPrior IntrusionState = 3
+	IntrusionStateSensorFailure IntrusionState = 4
+
+	LCDAccessViewAndModify LCDAccess = 1
+	LCDAccessViewOnly      LCDAccess = 2
+	LCDAccessDisabled      LCDAccess = 3
+
+	ConnectionStatusConnected    ConnectionStatus = 1
+	ConnectionStatusDisconnected ConnectionStatus = 2
+
+	SlotUsageOther     SlotUsage = 1
+	SlotUsageUnknown   SlotUsage = 2
+	SlotUsageAvailable SlotUsage = 3
+	SlotUsageInUse     SlotUsage = 4
+
+	BIOSSettingTypeEnumeration BIOSSettingType = 1
+	BIOSSettingTypeString      BIOSSettingType = 2
+	BIOSSettingTypeInteger     BIOSSettingType = 3
+
+	FirmwareTypeOther               FirmwareType = 0
+	FirmwareTypeBIOS                FirmwareType = 1
+	FirmwareTypeIDRAC               FirmwareType = 2
+	FirmwareTypeLifecycleController FirmwareType = 3
+	FirmwareTypeStorageController   FirmwareType = 4
+	FirmwareTypeStorageEnclosure    FirmwareType = 5
+
 	// NaN is an enum for fields that use the string 'N/A'.
 	NaN = -1 << 31
 )
 
+// Envelope models the status information that accompanies every omreport response.
+type Envelope struct {
+	UserRights UserRights `xml:"OMAUserRights"`
+	SMStatus   SMStatus   `xml:"SMStatus"`
+
+	// CollectedAt is the time at which omreport produced the response.
+	CollectedAt time.Time `xml:"-"`
+
+	// Stale is true if the response was served from the cache after it expired.
+	Stale bool `xml:"-"`
+}
+
+// envelope returns e. It allows reportXML to reach the Envelope embedded in any output.
+func (e *Envelope) envelope() *Envelope {
+	return e
+}
+
 // AboutOutput models the output of 'omreport about'.
 type AboutOutput struct {
+	Envelope
 	Version string `xml:"About>ProductVersion"`
 }
 
 // ChassisOutput models the output of 'omreport chassis'.
 type ChassisOutput struct {
+	Envelope
 	FansStatus            Status `xml:"Parent>fans>computedobjstatus"`
 	MemoryStatus          Status `xml:"Parent>memory>computedobjstatus"`
 	PowerSuppliesStatus   Status `xml:"Parent>powersupply>computedobjstatus"`
@@ -76,6 +172,7 @@ type ChassisOutput struct {
 
 // ChassisInfoOutput models the output of 'omreport chassis info'.
 type ChassisInfoOutput struct {
+	Envelope
 	ChassisList []ChassisEntry `xml:"ChassisList>Chassis"`
 }
 
@@ -90,28 +187,73 @@ type ChassisEntry struct {
 
 // ChassisBatteriesOutput models the output of 'omreport chassis batteries'.
 type ChassisBatteriesOutput struct {
+	Envelope
 	Probes []BatteryProbe `xml:"BatteryObj"`
 }
 
 // ChassisFansOutput models the output of 'omreport chassis fans'.
 type ChassisFansOutput struct {
+	Envelope
 	Probes []FanProbe `xml:"Chassis>FanProbeList>FanProbe"`
 }
 
 // ChassisProcessorsOutput models the output of 'omreport chassis processors'.
 type ChassisProcessorsOutput struct {
+	Envelope
 	Processors []Processor      `xml:"ProcessorList>ProcessorConn"`
 	Probes     []ProcessorProbe `xml:"CPUStatusProbeList>CPUStatusProbe"`
 }
 
+// ChassisNICsOutput models the output of 'omreport chassis nics'.
+type ChassisNICsOutput struct {
+	Envelope
+	NICs   []NIC     `xml:"NICList>NIC"`
+	Teams  []NICTeam `xml:"TeamList>Team"`
+	Status Status    `xml:"ObjStatus"`
+}
+
+// ChassisSlotsOutput models the output of 'omreport chassis slots'.
+type ChassisSlotsOutput struct {
+	Envelope
+	Slots []Slot `xml:"SlotList>Slot"`
+}
+
+// ChassisBIOSOutput models the output of 'omreport chassis bios'.
+// ReleaseDate is formatted as reported by the BIOS, usually MM/DD/YYYY.
+type ChassisBIOSOutput struct {
+	Envelope
+	Manufacturer string `xml:"BIOS>Manufacturer"`
+	Version      string `xml:"BIOS>Version"`
+	ReleaseDate  string `xml:"BIOS>ReleaseDate"`
+}
+
+// ChassisBIOSSetupOutput models the output of 'omreport chassis biossetup'.
+type ChassisBIOSSetupOutput struct {
+	Envelope
+	Settings []BIOSSetting `xml:"BIOSSetupList>BIOSSetup"`
+}
+
+// Setting returns the BIOS setting with the specified attribute name, e.g. "BootMode",
+// or nil if there is no such setting.
+func (o *ChassisBIOSSetupOutput) Setting(name string) *BIOSSetting {
+	for i := range o.Settings {
+		if o.Settings[i].Name == name {
+			return &o.Settings[i]
+		}
+	}
+	return nil
+}
+
 // ChassisPowerMonitoringOutput models the output of 'omreport chassis pwrmonitoring'.
 type ChassisPowerMonitoringOutput struct {
+	Envelope
 	Probes []PowerProbe `xml:"CurrentProbeList>CurrentProbe"`
 	Status Status       `xml:"ObjStatus"`
 }
 
 // ChassisMemoryOutput models the output of 'omreport chassis memory'.
 type ChassisMemoryOutput struct {
+	Envelope
 	TotalPhysicalMemorySize     float64 `xml:"MemoryInfo>TotalPhysMemorySize"`
 	AvailablePhysicalMemorySize float64 `xml:"MemoryInfo>AvailPhysMemorySize"`
 	Dimms                       []Dimm  `xml:"MemDevObj"`
@@ -120,31 +262,61 @@ type ChassisMemoryOutput struct {
 
 // ChassisPowerSuppliesOutput models the output of 'omreport chassis pwrsupplies'.
 type ChassisPowerSuppliesOutput struct {
+	Envelope
 	PowerSupplies []PowerSupply `xml:"Chassis>PowerSupplyList>PowerSupply"`
 }
 
 // ChassisTempsOutput models the output of 'omreport chassis temps'.
 type ChassisTempsOutput struct {
+	Envelope
 	Probes []TemperatureProbe `xml:"Chassis>TemperatureProbeList>TemperatureProbe"`
 }
 
+// ChassisVoltagesOutput models the output of 'omreport chassis volts'.
+type ChassisVoltagesOutput struct {
+	Envelope
+	Probes []VoltageProbe `xml:"Chassis>VoltageProbeList>VoltageProbe"`
+	Status Status         `xml:"Chassis>ObjStatus"`
+}
+
+// ChassisIntrusionOutput models the output of 'omreport chassis intrusion'.
+type ChassisIntrusionOutput struct {
+	Envelope
+	Probes []IntrusionProbe `xml:"IntrusionObj"`
+	Status Status           `xml:"computedobjstatus"`
+}
+
+// ChassisFrontPanelOutput models the output of 'omreport chassis frontpanel'.
+type ChassisFrontPanelOutput struct {
+	Envelope
+	PowerButtonEnabled bool      `xml:"FrontPanelObj>powerButtonEnabled"`
+	NMIButtonEnabled   bool      `xml:"FrontPanelObj>nmiButtonEnabled"`
+	LCDAccess          LCDAccess `xml:"FrontPanelObj>lcdAccess"`
+	LCDLines           []string  `xml:"FrontPanelObj>lcdLineList>lcdLine"`
+	Status             Status    `xml:"computedobjstatus"`
+}
+
 // StorageVDiskOutput models the output of 'omreport storage vdisk'.
 type StorageVDiskOutput struct {
+	Envelope
 	VDisks []VDisk `xml:"VirtualDisks>DCStorageObject"`
 }
 
 // StoragePDiskOutput models the output of 'omreport storage pdisk controller=<ID>'.
 type StoragePDiskOutput struct {
+	Envelope
 	PDisks []PDisk `xml:"ArrayDisks>DCStorageObject"`
 }
 
 // StorageControllerOutput models the output of 'omreport storage controller'.
 type StorageControllerOutput struct {
+	Envelope
 	Controllers []Controller `xml:"Controllers>DCStorageObject"`
 }
 
 // StorageEnclosureOutput models the output of 'omreport storage enclosure'.
 type StorageEnclosureOutput struct {
+	Envelope
 	Enclosures []Enclosure `xml:"Enclosures>DCStorageObject"`
 }
 
@@ -184,6 +356,84 @@ type Processor struct {
 	Status        Status  `xml:"status,attr"`
 }
 
+// NIC models a network interface described by omreport.
+// Speed is the negotiated link speed in Mbps, or zero when the link is down.
+type NIC struct {
+	ID               int              `xml:"index,attr"`
+	Name             string           `xml:"IfDescription"`
+	Vendor           string           `xml:"Vendor"`
+	Description      string           `xml:"Description"`
+	DriverVersion    string           `xml:"DriverVersion"`
+	MACAddress       string           `xml:"MACAddr"`
+	IPv4Addresses    []string         `xml:"IPv4AddrList>IPv4Addr"`
+	IPv6Addresses    []string         `xml:"IPv6AddrList>IPv6Addr"`
+	Speed            int              `xml:"Speed"`
+	ConnectionStatus ConnectionStatus `xml:"ConnectionStatus"`
+	Team             string           `xml:"TeamName"`
+	Status           Status           `xml:"objstatus"`
+}
+
+// NICTeam models a team of network interfaces, e.g. a bond, described by omreport.
+// Members are the names of the member interfaces.
+type NICTeam struct {
+	ID               int              `xml:"index,attr"`
+	Name             string           `xml:"IfDescription"`
+	Description      string           `xml:"Description"`
+	MACAddress       string           `xml:"MACAddr"`
+	ConnectionStatus ConnectionStatus `xml:"ConnectionStatus"`
+	Members          []string         `xml:"MemberList>Member"`
+	Status           Status           `xml:"objstatus"`
+}
+
+// Slot models a PCI slot described by omreport. DataBusWidth is the number of lanes of the slot.
+// The PCI bus, device and function are those of the adapter installed in the slot, if any.
+type Slot struct {
+	ID           int       `xml:"SlotID"`
+	Name         string    `xml:"SlotName"`
+	Adapter      string    `xml:"Adapter>Description"`
+	Type         string    `xml:"SlotType"`
+	DataBusWidth int       `xml:"DataBusWidth"`
+	Usage        SlotUsage `xml:"SlotUsage"`
+	PCIBus       int       `xml:"PCIBusNo"`
+	PCIDevice    int       `xml:"PCIDeviceNum"`
+	PCIFunction  int       `xml:"PCIFunctionNum"`
+}
+
+// Controller returns the controller installed in the slot, found by PCI bus, device and function
+// among controllers, e.g. those returned by StorageController. Returns nil if the slot is not
+// in use or none of the controllers is installed in it.
+func (s *Slot) Controller(controllers []Controller) *Controller {
+	if s.Usage != SlotUsageInUse {
+		return nil
+	}
+	for i := range controllers {
+		c := &controllers[i]
+		if c.PCIBus == s.PCIBus && c.PCIDevice == s.PCIDevice && c.PCIFunction == s.PCIFunction {
+			return c
+		}
+	}
+	return nil
+}
+
+// BIOSSetting models a BIOS setting described by omreport. AllowedValues lists the values
+// an enumeration setting may be set to, and is empty for settings of other types.
+type BIOSSetting struct {
+	Name          string          `xml:"Attribute"`
+	DisplayName   string          `xml:"DisplayName"`
+	Type          BIOSSettingType `xml:"Type"`
+	Value         string          `xml:"CurrentValue"`
+	AllowedValues []string        `xml:"PossibleValueList>PossibleValue"`
+	ReadOnly      bool            `xml:"ReadOnly"`
+}
+
+// Int returns the value of an integer setting.
+func (s *BIOSSetting) Int() (int, error) {
+	if s.Type != BIOSSettingTypeInteger {
+		return 0, fmt.Errorf("BIOS setting %s is not an integer", s.Name)
+	}
+	return strconv.Atoi(s.Value)
+}
+
 // ProcessorProbe models a CPU probe described by omreport.
 type ProcessorProbe struct {
 	ID                   int    `xml:"index,attr"`
@@ -205,20 +455,62 @@ type TemperatureProbe struct {
 	Location string  `xml:"ProbeLocation"`
 }
 
+// VoltageProbe models a voltage probe described by omreport.
+// Readings and thresholds are in millivolts, and thresholds that are not set are NaN.
+// Discrete probes only report whether the voltage is in range through State.
+type VoltageProbe struct {
+	ID                         int          `xml:"index,attr"`
+	Reading                    float64      `xml:"ProbeReading"`
+	State                      VoltageState `xml:"ProbeDiscreteReading"`
+	Status                     Status       `xml:"ProbeStatus"`
+	Location                   string       `xml:"ProbeLocation"`
+	MinNonRecoverableThreshold float64      `xml:"ProbeThresholds>LNRThreshold"`
+	MinCriticalThreshold       float64      `xml:"ProbeThresholds>LCThreshold"`
+	MinNonCriticalThreshold    float64      `xml:"ProbeThresholds>LNCThreshold"`
+	MaxNonCriticalThreshold    float64      `xml:"ProbeThresholds>UNCThreshold"`
+	MaxCriticalThreshold       float64      `xml:"ProbeThresholds>UCThreshold"`
+	MaxNonRecoverableThreshold float64      `xml:"ProbeThresholds>UNRThreshold"`
+}
+
+// Discrete returns true if the probe only reports whether the voltage is in range
+// rather than a reading.
+func (p *VoltageProbe) Discrete() bool {
+	return p.State != 0
+}
+
+// IntrusionProbe models a chassis intrusion sensor described by omreport.
+type IntrusionProbe struct {
+	ID       int            `xml:"instance,attr"`
+	Location string         `xml:"ProbeLocation"`
+	Type     IntrusionType  `xml:"intrusionType"`
+	State    IntrusionState `xml:"intrusionState"`
+	Status   Status         `xml:"objstatus"`
+}
+
+// Breached returns true if the sensor detected that the chassis is or was opened.
+func (p *IntrusionProbe) Breached() bool {
+	return p.State == IntrusionStateBreached || p.State == IntrusionStateBreachedPrior
+}
+
 // Controller models a controller described by omreport.
 type Controller struct {
-	ID     int    `xml:"ControllerNum"`
-	Name   string `xml:"Name"`
-	Status Status `xml:"ObjStatus"`
-	State  State  `xml:"ObjState"`
+	ID              int    `xml:"ControllerNum"`
+	Name            string `xml:"Name"`
+	Status          Status `xml:"ObjStatus"`
+	State           State  `xml:"ObjState"`
+	FirmwareVersion string `xml:"FirmwareVer"`
+	PCIBus          int    `xml:"PCIBusNo"`
+	PCIDevice       int    `xml:"PCIDeviceNum"`
+	PCIFunction     int    `xml:"PCIFunctionNum"`
 }
 
 // Enclosure models a enclosure described by omreport.
 type Enclosure struct {
-	ID           int    `xml:"EnclosureID"`
-	ControllerID int    `xml:"ControllerNum"`
-	Status       Status `xml:"ObjStatus"`
-	State        State  `xml:"ObjState"`
+	ID              int    `xml:"EnclosureID"`
+	ControllerID    int    `xml:"ControllerNum"`
+	Status          Status `xml:"ObjStatus"`
+	State           State  `xml:"ObjState"`
+	FirmwareVersion string `xml:"FirmwareVer"`
 }
 
 // VDisk models a virtual disk described by omreport.
@@ -233,6 +525,15 @@ type VDisk struct {
 	Size        int         `xml:"Length"`
 }
 
+// ESMLogEntry models an entry of the embedded systems management (ESM) log described by omreport.
+// Time is formatted as reported by omreport, e.g. "Tue Mar 09 17:40:03 2021".
+type ESMLogEntry struct {
+	ID          int    `xml:"index,attr"`
+	Severity    Status `xml:"Severity"`
+	Time        string `xml:"Date"`
+	Description string `xml:"Description"`
+}
+
 // PDisk models a physical disk described by omreport.
 type PDisk struct {
 	AttributesMask string      `xml:"AttributesMask"`
@@ -278,6 +579,9 @@ type Fans struct {
 }
 
 // Voltages models a group of voltage probes and their status.
+//
+// Deprecated: Voltages does not match the output of 'omreport chassis volts'.
+// Use ChassisVoltagesOutput and VoltageProbe instead.
 type Voltages struct {
 	Probes []Probe `xml:"VoltageObj"`
 	Status Status  `xml:"computedobjstatus"`
@@ -294,7 +598,8 @@ type Dimm struct {
 	SingleBitErrors int    `xml:"sbErrCount"`
 }
 
-// Probe models a generic probe.
+// Probe models a generic probe. Voltage probes reported by
+// 'omreport chassis volts' are modeled by VoltageProbe.
 type Probe struct {
 	ID                      int     `xml:"instance,attr"`
 	Name                    string  `xml:"ProbeLocation"`
@@ -329,6 +634,109 @@ func (s *Status) String() string {
 	}
 }
 
+func (s *SMStatus) String() string {
+	switch *s {
+	case SMStatusUnsuccessful:
+		return "Unsuccessful"
+	case SMStatusSuccess:
+		return "Success"
+	case SMStatusNotImplemented:
+		return "Not implemented"
+	case SMStatusInvalidParameter:
+		return "Invalid parameter"
+	case SMStatusNoSuchDevice:
+		return "No such device"
+	case SMStatusAccessDenied:
+		return "Access denied"
+	default:
+		return fmt.Sprintf("Unknown SMStatus code %d", int(*s))
+	}
+}
+
+func (t *FirmwareType) String() string {
+	switch *t {
+	case FirmwareTypeOther:
+		return "Other"
+	case FirmwareTypeBIOS:
+		return "BIOS"
+	case FirmwareTypeIDRAC:
+		return "iDRAC"
+	case FirmwareTypeLifecycleController:
+		return "Lifecycle Controller"
+	case FirmwareTypeStorageController:
+		return "Storage Controller"
+	case FirmwareTypeStorageEnclosure:
+		return "Storage Enclosure"
+	default:
+		return fmt.Sprintf("Unknown firmware type %d", int(*t))
+	}
+}
+
+func (t *BIOSSettingType) String() string {
+	switch *t {
+	case BIOSSettingTypeEnumeration:
+		return "Enumeration"
+	case BIOSSettingTypeString:
+		return "String"
+	case BIOSSettingTypeInteger:
+		return "Integer"
+	default:
+		return fmt.Sprintf("Unknown BIOS setting type %d", int(*t))
+	}
+}
+
+func (u *SlotUsage) String() string {
+	switch *u {
+	case SlotUsageOther:
+		return "Other"
+	case SlotUsageUnknown:
+		return "Unknown"
+	case SlotUsageAvailable:
+		return "Available"
+	case SlotUsageInUse:
+		return "In Use"
+	default:
+		return fmt.Sprintf("Unknown slot usage %d", int(*u))
+	}
+}
+
+func (s *ConnectionStatus) String() string {
+	switch *s {
+	case ConnectionStatusConnected:
+		return "Connected"
+	case ConnectionStatusDisconnected:
+		return "Disconnected"
+	default:
+		return fmt.Sprintf("Unknown connection status %d", int(*s))
+	}
+}
+
+func (s *IntrusionState) String() string {
+	switch *s {
+	case IntrusionStateNotBreached:
+		return "Not Breached"
+	case IntrusionStateBreached:
+		return "Breached"
+	case IntrusionStateBreachedPrior:
+		return "Breached Prior"
+	case IntrusionStateSensorFailure:
+		return "Sensor Failure"
+	default:
+		return fmt.Sprintf("Unknown intrusion state %d", int(*s))
+	}
+}
+
+func (s *VoltageState) String() string {
+	switch *s {
+	case VoltageStateGood:
+		return "Good"
+	case VoltageStateBad:
+		return "Bad"
+	default:
+		return fmt.Sprintf("Unknown voltage state %d", int(*s))
+	}
+}
+
 func (s *State) String() string {
 	switch *s {
 	case StateOnline:
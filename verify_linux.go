@@ -0,0 +1,99 @@
+//go:build linux
+// +build linux
+
+package omreport
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// verifiedCommand opens the binary once, verifies it through the open file and returns a command
+// that executes a sealed in-memory copy of the bytes that were verified through /proc/self/fd.
+// The bytes that were verified are therefore the bytes that run, even if the binary is replaced
+// or modified in place in the meantime. The returned file must be closed once the command has started.
+//
+// The copy is closed on exec, so the executed program does not inherit it. Scripts, e.g. wrappers
+// installed in place of omcliproxy, are the exception: their interpreter opens the script only
+// after the exec, so the copy is inherited by the script and every process it starts as
+// descriptor 3.
+//
+// On kernels without memfd_create, the verified file itself is executed, which is only safe if
+// nobody but root can modify it in place. StrictPermissions is therefore required on those kernels.
+func (om *OMReport) verifiedCommand(argv []string) (*exec.Cmd, *os.File, error) {
+	path := om.binaryPath()
+	if om.strictPermissions {
+		if err := checkPermissions(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	exe, err := om.verifiedCopy(f)
+	if exe != f {
+		f.Close()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := make([]byte, 2)
+	if _, err := exe.ReadAt(header, 0); err != nil && err != io.EOF {
+		exe.Close()
+		return nil, nil, err
+	}
+	cmd := exec.Command(path, argv...)
+	if string(header) == "#!" {
+		// The first extra file is inherited as descriptor 3, which the interpreter
+		// refers to as /proc/self/fd/3 when it opens the script.
+		cmd.Path = "/proc/self/fd/3"
+		cmd.ExtraFiles = []*os.File{exe}
+	} else {
+		// The child process execs the descriptor it shares with this process until the exec closes it.
+		cmd.Path = fmt.Sprintf("/proc/self/fd/%d", exe.Fd())
+	}
+	return cmd, exe, nil
+}
+
+// verifiedCopy verifies the binary read from f and returns a sealed in-memory copy of the bytes
+// that were verified, or f itself if the kernel does not support memfd_create and StrictPermissions
+// is enabled. The returned file is open read-only and closed on exec.
+func (om *OMReport) verifiedCopy(f *os.File) (*os.File, error) {
+	h := sha256.New()
+	mem, err := createMemfd(filepath.Base(f.Name()))
+	switch {
+	case err == syscall.ENOSYS && om.strictPermissions:
+		if _, err := io.Copy(h, f); err != nil {
+			return nil, err
+		}
+		if err := om.checkChecksum(h.Sum(nil)); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case err == syscall.ENOSYS:
+		return nil, errors.New("enhanced security mode requires StrictPermissions on kernels without memfd_create")
+	case err != nil:
+		return nil, err
+	}
+	defer mem.Close()
+
+	if _, err := io.Copy(io.MultiWriter(h, mem), f); err != nil {
+		return nil, err
+	}
+	if err := om.checkChecksum(h.Sum(nil)); err != nil {
+		return nil, err
+	}
+	if err := sealMemfd(mem); err != nil {
+		return nil, err
+	}
+	// A file cannot be executed while a descriptor open for writing refers to it.
+	return os.OpenFile(fmt.Sprintf("/proc/self/fd/%d", mem.Fd()), os.O_RDONLY, 0)
+}
@@ -0,0 +1,115 @@
+//go:build linux
+// +build linux
+
+package omreport
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOMReport_verifiedCommand(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(".", "")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err)
+	}()
+	binaryPath := filepath.Join(tmpDir, "omcliproxy")
+	err = ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho \"$0\"\n"), 0755)
+	require.NoError(t, err)
+
+	om, err := NewOMReporter(&Config{
+		OMCLIProxyPath:       binaryPath,
+		EnhancedSecurityMode: true,
+	})
+	require.NoError(t, err)
+
+	t.Run("verified binary is executed through its descriptor", func(t *testing.T) {
+		out, err := om.ReportContext(context.Background(), "chassis")
+		require.NoError(t, err)
+		assert.Equal(t, "/proc/self/fd/3\n", string(out))
+	})
+	t.Run("replaced binary is not executed", func(t *testing.T) {
+		replacement := filepath.Join(tmpDir, "replacement")
+		err := ioutil.WriteFile(replacement, []byte("#!/bin/sh\necho replaced\n"), 0755)
+		require.NoError(t, err)
+		require.NoError(t, os.Rename(replacement, binaryPath))
+
+		_, err = om.ReportContext(context.Background(), "chassis", "fans")
+		require.Error(t, err, "replaced omcliproxy binary should be considered suspicious")
+	})
+	t.Run("binary replaced after verification is not executed", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho verified\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{
+			OMCLIProxyPath:       binaryPath,
+			EnhancedSecurityMode: true,
+		})
+		require.NoError(t, err)
+
+		cmd, f, err := om.verifiedCommand(om.argv([]string{"chassis"}))
+		require.NoError(t, err)
+		defer f.Close()
+
+		// Swap the binary between its verification and its execution.
+		replacement := filepath.Join(tmpDir, "replacement")
+		err = ioutil.WriteFile(replacement, []byte("#!/bin/sh\necho replaced\n"), 0755)
+		require.NoError(t, err)
+		require.NoError(t, os.Rename(replacement, binaryPath))
+
+		out, err := (&CommandExecutor{}).execute(context.Background(), cmd)
+		require.NoError(t, err)
+		assert.Equal(t, "verified\n", string(out), "the verified binary should run, not its replacement")
+	})
+	t.Run("binary modified in place after verification is not executed", func(t *testing.T) {
+		err := ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho verified\n"), 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{
+			OMCLIProxyPath:       binaryPath,
+			EnhancedSecurityMode: true,
+		})
+		require.NoError(t, err)
+
+		cmd, f, err := om.verifiedCommand(om.argv([]string{"chassis"}))
+		require.NoError(t, err)
+		defer f.Close()
+
+		// Overwrite the verified file itself between its verification and its execution.
+		err = ioutil.WriteFile(binaryPath, []byte("#!/bin/sh\necho modified\n"), 0755)
+		require.NoError(t, err)
+
+		out, err := (&CommandExecutor{}).execute(context.Background(), cmd)
+		require.NoError(t, err)
+		assert.Equal(t, "verified\n", string(out), "the verified bytes should run, not the modified file")
+	})
+	t.Run("executables do not inherit the verified binary", func(t *testing.T) {
+		shell, err := ioutil.ReadFile("/bin/sh")
+		require.NoError(t, err)
+		if string(shell[:4]) != "\x7fELF" {
+			t.Skip("/bin/sh is not an ELF executable")
+		}
+		err = ioutil.WriteFile(binaryPath, shell, 0755)
+		require.NoError(t, err)
+		om, err := NewOMReporter(&Config{
+			OMCLIProxyPath:       binaryPath,
+			EnhancedSecurityMode: true,
+		})
+		require.NoError(t, err)
+
+		cmd, f, err := om.verifiedCommand([]string{"-c", "ls -l /proc/$$/fd"})
+		require.NoError(t, err)
+		defer f.Close()
+
+		out, err := (&CommandExecutor{}).execute(context.Background(), cmd)
+		require.NoError(t, err)
+		assert.NotContains(t, string(out), "memfd:")
+		assert.NotContains(t, string(out), binaryPath)
+	})
+}
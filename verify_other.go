@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package omreport
+
+import (
+	"os"
+	"os/exec"
+)
+
+// verifiedCommand verifies the binary and returns a command that executes it. Unlike on Linux,
+// the binary is reopened by path when it is executed, so a binary replaced right after it was
+// verified may still run. The returned file is always nil.
+func (om *OMReport) verifiedCommand(argv []string) (*exec.Cmd, *os.File, error) {
+	if err := om.SuspiciousOMCLIProxyBinary(); err != nil {
+		return nil, nil, err
+	}
+	return exec.Command(om.binaryPath(), argv...), nil, nil
+}
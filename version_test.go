@@ -0,0 +1,190 @@
+package omreport
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]Version{
+		"8.5.0":       {Major: 8, Minor: 5},
+		"9.3.0.2":     {Major: 9, Minor: 3},
+		"7.4":         {Major: 7, Minor: 4},
+		"9.5.0-4063":  {Major: 9, Minor: 5},
+		" 10.1.0 ":    {Major: 10, Minor: 1},
+		"8.0.1 (A00)": {Major: 8, Patch: 1},
+	}
+	for s, expected := range tests {
+		v, err := ParseVersion(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, v, s)
+	}
+	for _, s := range []string{"", "eight", "8.x", "-1.0"} {
+		_, err := ParseVersion(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	assert.Equal(t, 0, Version{8, 5, 0}.Compare(Version{8, 5, 0}))
+	assert.Equal(t, -1, Version{7, 4, 1}.Compare(Version{8, 0, 0}))
+	assert.Equal(t, 1, Version{9, 1, 0}.Compare(Version{9, 0, 3}))
+	assert.Equal(t, "8.5.0", Version{8, 5, 0}.String())
+}
+
+func TestOMReport_Version(t *testing.T) {
+	om, err := NewFixtureReporter("testdata")
+	require.NoError(t, err)
+
+	out, err := om.Version()
+	require.NoError(t, err)
+	assert.Equal(t, Version{Major: 8, Minor: 5}, out.Version)
+	require.Len(t, out.Components, 51)
+	assert.Equal(t, Component{Name: "RAC Command Interface", Version: "7.1.0", ID: "racadm4"}, out.Components[0])
+}
+
+func TestOMReport_VersionSpecific(t *testing.T) {
+	newReporter := func(version string) (*OMReport, *int32) {
+		var abouts int32
+		fixtures := &fixtureExecutor{dir: "testdata"}
+		om, err := NewOMReporter(&Config{
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				if args[1] == "about" {
+					atomic.AddInt32(&abouts, 1)
+				}
+				return fixtures.Execute(ctx, name, args...)
+			}),
+			OMSAVersion: version,
+		})
+		require.NoError(t, err)
+		return om, &abouts
+	}
+
+	t.Run("release is only detected for version-specific commands", func(t *testing.T) {
+		om, abouts := newReporter("")
+		_, err := om.ChassisTemps()
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(abouts))
+
+		commandVersions["chassis temps"] = versionRange{Min: Version{Major: 8}}
+		defer delete(commandVersions, "chassis temps")
+		_, err = om.ChassisTemps()
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(abouts))
+	})
+	t.Run("unsupported release", func(t *testing.T) {
+		commandVersions["chassis temps"] = versionRange{Min: Version{Major: 8}, Max: Version{Major: 9}}
+		defer delete(commandVersions, "chassis temps")
+
+		for version, supported := range map[string]bool{"7.4.0": false, "8.5.0": true, "9.1.0": false} {
+			om, _ := newReporter(version)
+			_, err := om.ChassisTemps()
+			if supported {
+				assert.NoError(t, err, version)
+			} else {
+				assert.Equal(t, ErrUnsupported, err, version)
+			}
+			err = om.StreamReport(context.Background(), "Chassis", func(decode func(v interface{}) error) error { return nil }, "chassis", "temps")
+			if supported {
+				assert.NoError(t, err, version)
+			} else {
+				assert.Equal(t, ErrUnsupported, err, version)
+			}
+		}
+	})
+	t.Run("version-specific decoder", func(t *testing.T) {
+		decoders["chassis temps"] = []versionedDecoder{{
+			versions: versionRange{Max: Version{Major: 8}},
+			decode: func(data []byte, v interface{}) error {
+				v.(*ChassisTempsOutput).Probes = []TemperatureProbe{{Location: "decoded for 7.x"}}
+				return nil
+			},
+		}}
+		defer delete(decoders, "chassis temps")
+
+		om, _ := newReporter("7.4.0")
+		out, err := om.ChassisTemps()
+		require.NoError(t, err)
+		require.Len(t, out.Probes, 1)
+		assert.Equal(t, "decoded for 7.x", out.Probes[0].Location)
+
+		om, _ = newReporter("8.5.0")
+		out, err = om.ChassisTemps()
+		require.NoError(t, err)
+		assert.NotEqual(t, "decoded for 7.x", out.Probes[0].Location)
+	})
+	t.Run("invalid configured version", func(t *testing.T) {
+		_, err := NewOMReporter(&Config{
+			Executor:    &fixtureExecutor{dir: "testdata"},
+			OMSAVersion: "latest",
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestOMReport_VersionDetection(t *testing.T) {
+	fixtures := &fixtureExecutor{dir: "testdata"}
+	commandVersions["chassis temps"] = versionRange{}
+	defer delete(commandVersions, "chassis temps")
+
+	t.Run("callers do not wait for detection beyond their deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		om, err := NewOMReporter(&Config{
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				if args[1] == "about" {
+					select {
+					case <-release:
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					}
+				}
+				return fixtures.Execute(ctx, name, args...)
+			}),
+		})
+		require.NoError(t, err)
+
+		detected := make(chan error, 1)
+		go func() {
+			_, err := om.ChassisTemps()
+			detected <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = om.ChassisTempsContext(ctx)
+		assert.Equal(t, ErrTimeout, err)
+		assert.True(t, time.Since(start) < time.Second, "caller should give up at its own deadline")
+
+		close(release)
+		require.NoError(t, <-detected)
+		version, known := om.omsaVersion(context.Background())
+		assert.True(t, known)
+		assert.Equal(t, Version{Major: 8, Minor: 5}, version)
+	})
+	t.Run("failed detection is not retried on every command", func(t *testing.T) {
+		var abouts int32
+		om, err := NewOMReporter(&Config{
+			Executor: ExecutorFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				if args[1] == "about" {
+					atomic.AddInt32(&abouts, 1)
+					return nil, &ExecError{Argv: args, ExitCode: 1, Stderr: "service not running"}
+				}
+				return fixtures.Execute(ctx, name, args...)
+			}),
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := om.ChassisTemps()
+			require.NoError(t, err, "commands should run when the version cannot be detected")
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&abouts))
+	})
+}